@@ -0,0 +1,45 @@
+package imterm
+
+// Border is the set of glyphs used to draw a frame, the markers around its label, and the scroll arrows and
+// scrollbars that sit on it.  The zero Border is unset, and picks up the border of the next style down.
+type Border struct {
+	Horizontal, Vertical                       rune
+	TopLeft, TopRight, BottomLeft, BottomRight rune
+	LabelLeft, LabelRight                      rune
+	Up, Down, Left, Right                      rune
+	Track, Thumb                               rune
+
+	hidden bool
+}
+
+var (
+	BorderSingle  = Border{'─', '│', '┌', '┐', '└', '┘', '◄', '►', '▲', '▼', '◄', '►', '░', '█', false}
+	BorderDouble  = Border{'═', '║', '╔', '╗', '╚', '╝', '◄', '►', '▲', '▼', '◄', '►', '░', '█', false}
+	BorderRounded = Border{'─', '│', '╭', '╮', '╰', '╯', '◄', '►', '▲', '▼', '◄', '►', '░', '█', false}
+	BorderHeavy   = Border{'━', '┃', '┏', '┓', '┗', '┛', '◄', '►', '▲', '▼', '◄', '►', '░', '█', false}
+	BorderASCII   = Border{'-', '|', '+', '+', '+', '+', '<', '>', '^', 'v', '<', '>', '.', '#', false}
+
+	// BorderNone draws nothing but the label, and hands the rest of the box to the widget's contents
+	BorderNone = Border{hidden: true}
+)
+
+func (bd Border) none() bool {
+	return bd.hidden
+}
+
+// ascii reports whether the border is drawn without any box drawing glyphs
+func (bd Border) ascii() bool {
+	return bd.Horizontal < 0x80 && bd.Vertical < 0x80 && bd.BottomRight < 0x80
+}
+
+// frameInset returns the space the border of class and its label take up on each side of a widget.  Without a
+// border, a label still takes the top row.
+func (it *Imterm) frameInset(class, label string) Spacing {
+	if !it.GetStyle(class).Border.none() {
+		return Even(1)
+	}
+	if label != "" {
+		return Spacing{Top: 1}
+	}
+	return Spacing{}
+}
@@ -0,0 +1,159 @@
+package imterm
+
+type childState struct {
+	xscroll, yscroll int
+
+	contentW, contentH int
+}
+
+type childFrame struct {
+	flow   flow
+	id     string
+	box    Box
+	state  *childState
+	origin Box
+	view   Box
+}
+
+// Start a framed child region with its own layout.  Items placed before the matching EndChild are clipped to the
+// region, and it can be scrolled with the mouse wheel or scrollbars when they don't fit.  A Fit size shrinks the
+// region to wrap its contents.
+func (it *Imterm) BeginChild(w, h Size, label string) {
+	id := it.getID(label)
+	it.setLast(id)
+	state := it.getState(id, &childState{}).(*childState)
+	var measure measureFunc
+	if state.contentW > 0 {
+		fw, fh := it.frameInset("child.border", label).size()
+		measure = fixedSize(state.contentW+fw, state.contentH+fh)
+	}
+	b := it.getFitBox(w, h, "child", measure)
+
+	it.PushClip(b.x, b.y, b.w, b.h)
+	view := it.frame(b, label, "child.border")
+	it.PopClip()
+
+	it.childStack = append(it.childStack, childFrame{it.flow, id, b, state, Box{view.x - state.xscroll, view.y - state.yscroll, 0, 0}, view})
+	it.PushClip(view.x, view.y, view.w, view.h)
+	it.flow = flow{
+		xPos:        view.x - state.xscroll,
+		yPos:        view.y - state.yscroll,
+		columnX:     view.x - state.xscroll,
+		columnY:     view.y - state.yscroll,
+		columnWidth: view.w,
+		bottom:      view.y + view.h - state.yscroll,
+	}
+}
+
+// Finish the current child region
+func (it *Imterm) EndChild() {
+	cf := it.childStack[len(it.childStack)-1]
+	it.childStack = it.childStack[:len(it.childStack)-1]
+
+	bottom := it.yPos
+	if it.columnMaxY > bottom {
+		bottom = it.columnMaxY
+	}
+	contentW, contentH := it.maxX-cf.origin.x, bottom-cf.origin.y
+
+	it.PopClip()
+	it.flow = cf.flow
+
+	b, state := cf.box, cf.state
+	state.contentW, state.contentH = contentW, contentH
+	it.PushClip(b.x, b.y, b.w, b.h)
+	defer it.PopClip()
+	bd := it.GetStyle("child.border").Border
+	viewW, viewH := cf.view.w, cf.view.h
+
+	if !it.wheelUsed {
+		switch it.CheckClick(b.x, b.y, b.w, b.h) {
+		case MouseWheelUp:
+			state.yscroll--
+			it.wheelUsed = true
+		case MouseWheelDown:
+			state.yscroll++
+			it.wheelUsed = true
+		}
+	}
+
+	s := it.GetStyle("child.scrollbar")
+	if contentH > viewH && !bd.none() {
+		state.yscroll = it.scrollbar(Box{b.x + b.w - 1, b.y + 1, 1, viewH}, true, state.yscroll, contentH, viewH, bd, s)
+	}
+	if contentW > viewW && !bd.none() {
+		state.xscroll = it.scrollbar(Box{b.x + 1, b.y + b.h - 1, viewW, 1}, false, state.xscroll, contentW, viewW, bd, s)
+	}
+	state.yscroll = clampScroll(state.yscroll, contentH, viewH)
+	state.xscroll = clampScroll(state.xscroll, contentW, viewW)
+}
+
+func clampScroll(scroll, content, view int) int {
+	if scroll > content-view {
+		scroll = content - view
+	}
+	if scroll < 0 {
+		scroll = 0
+	}
+	return scroll
+}
+
+// scrollbar draws a scrollbar filling b with the glyphs of bd and returns the scroll offset updated by any clicks
+// on it
+func (it *Imterm) scrollbar(b Box, vertical bool, scroll, content, view int, bd Border, s CalcedStyle) int {
+	size := b.w
+	if vertical {
+		size = b.h
+	}
+	if size < 2 || content <= view {
+		return scroll
+	}
+	cell := func(i int) (int, int) {
+		if vertical {
+			return b.x, b.y + i
+		}
+		return b.x + i, b.y
+	}
+
+	track := size - 2
+	thumb := track * view / content
+	if thumb < 1 {
+		thumb = 1
+	}
+	pos := 0
+	if content > view {
+		pos = (track - thumb) * clampScroll(scroll, content, view) / (content - view)
+	}
+
+	back, fwd := bd.Left, bd.Right
+	if vertical {
+		back, fwd = bd.Up, bd.Down
+	}
+	for i := 0; i < size; i++ {
+		x, y := cell(i)
+		ch := bd.Track
+		switch {
+		case i == 0:
+			ch = back
+		case i == size-1:
+			ch = fwd
+		case i-1 >= pos && i-1 < pos+thumb:
+			ch = bd.Thumb
+		}
+		it.setCell(x, y, ch, s.Fg, s.Bg)
+		if it.CheckClick(x, y, 1, 1) != MouseLeft {
+			continue
+		}
+		switch {
+		case i == 0:
+			scroll--
+		case i == size-1:
+			scroll++
+		case i-1 < pos:
+			scroll -= view
+		case i-1 >= pos+thumb:
+			scroll += view
+		}
+	}
+	return scroll
+}
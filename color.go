@@ -0,0 +1,148 @@
+package imterm
+
+// Colors live in the low 26 bits of an Attribute, either as one of the basic colors, an index into the 256 color
+// palette, or a 24 bit RGB triplet
+const (
+	colorIndexed Attribute = 1 << 24
+	colorRGB     Attribute = 1 << 25
+	colorMask    Attribute = 1<<26 - 1
+)
+
+// ColorMode is how many colors a Screen can show
+type ColorMode int
+
+const (
+	// The eight basic colors
+	Colors8 ColorMode = iota
+	// The 256 color palette, starting with the basic colors and their bright versions
+	Colors256
+	// 24 bit true color
+	ColorsRGB
+)
+
+// ColorScreen is a Screen that can show more than the basic colors.  Colors are downsampled to its ColorMode
+// before being passed to SetCell and Clear, while a Screen without it only ever gets the basic colors.
+type ColorScreen interface {
+	Screen
+	ColorMode() ColorMode
+}
+
+// Returns color n of the 256 color palette
+func Color256(n uint8) Attribute {
+	return colorIndexed | Attribute(n)
+}
+
+// Returns a true color
+func RGB(r, g, b uint8) Attribute {
+	return colorRGB | Attribute(r)<<16 | Attribute(g)<<8 | Attribute(b)
+}
+
+// Returns the color of a, without any attributes
+func (a Attribute) Color() Attribute {
+	return a & colorMask
+}
+
+// Returns the attributes of a, without its color
+func (a Attribute) Attrs() Attribute {
+	return a &^ colorMask
+}
+
+var basicRGB = [16][3]uint8{
+	{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0}, {0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+	{127, 127, 127}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0}, {92, 92, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+}
+
+var cubeLevels = [6]uint8{0, 95, 135, 175, 215, 255}
+
+// Returns the red, green and blue parts of a's color, as the usual xterm palette shows them.  ok is false for
+// ColorDefault, which is up to the terminal.
+func (a Attribute) RGB() (r, g, b uint8, ok bool) {
+	c := a.Color()
+	switch {
+	case c&colorRGB != 0:
+		return uint8(c >> 16), uint8(c >> 8), uint8(c), true
+	case c&colorIndexed != 0:
+		n := int(c & 0xff)
+		switch {
+		case n < 16:
+			p := basicRGB[n]
+			return p[0], p[1], p[2], true
+		case n < 232:
+			n -= 16
+			return cubeLevels[n/36], cubeLevels[n/6%6], cubeLevels[n%6], true
+		}
+		v := uint8(8 + (n-232)*10)
+		return v, v, v, true
+	case c > ColorDefault && c <= ColorWhite:
+		p := basicRGB[c-ColorBlack]
+		return p[0], p[1], p[2], true
+	}
+	return 0, 0, 0, false
+}
+
+func distance(r1, g1, b1, r2, g2, b2 uint8) int {
+	dr, dg, db := int(r1)-int(r2), int(g1)-int(g2), int(b1)-int(b2)
+	return dr*dr + dg*dg + db*db
+}
+
+// nearest returns the index of the palette entry closest to r, g, b
+func nearest(r, g, b uint8, n int, entry func(i int) (uint8, uint8, uint8)) int {
+	best, bestD := 0, -1
+	for i := 0; i < n; i++ {
+		er, eg, eb := entry(i)
+		if d := distance(r, g, b, er, eg, eb); bestD < 0 || d < bestD {
+			best, bestD = i, d
+		}
+	}
+	return best
+}
+
+// Returns a with its color replaced by the closest one that can be shown in mode.  Basic colors are left alone,
+// 256 color indexes become basic colors in Colors8, and true colors become the nearest palette color.
+func (a Attribute) Downsample(mode ColorMode) Attribute {
+	c := a.Color()
+	if mode == ColorsRGB || c <= ColorWhite || (mode == Colors256 && c&colorIndexed != 0) {
+		return a
+	}
+	r, g, b, _ := c.RGB()
+	if mode == Colors256 {
+		n := nearest(r, g, b, 256, func(i int) (uint8, uint8, uint8) {
+			r, g, b, _ := Color256(uint8(i)).RGB()
+			return r, g, b
+		})
+		return a.Attrs() | Color256(uint8(n))
+	}
+	if c&colorIndexed != 0 && c&0xff < 16 {
+		return a.Attrs() | (ColorBlack + c&7)
+	}
+	n := nearest(r, g, b, 8, func(i int) (uint8, uint8, uint8) {
+		p := basicRGB[i]
+		return p[0], p[1], p[2]
+	})
+	return a.Attrs() | (ColorBlack + Attribute(n))
+}
+
+// downsample is Downsample to the screen's color mode, remembering each color it has worked out, as finding the
+// nearest palette color is too slow to do for every cell
+func (it *Imterm) downsample(a Attribute) Attribute {
+	c := a.Color()
+	if it.mode == ColorsRGB || c <= ColorWhite || (it.mode == Colors256 && c&colorIndexed != 0) {
+		return a
+	}
+	d, ok := it.colors[c]
+	if !ok {
+		if len(it.colors) >= 1<<16 {
+			it.colors = map[Attribute]Attribute{}
+		}
+		d = c.Downsample(it.mode)
+		it.colors[c] = d
+	}
+	return a.Attrs() | d
+}
+
+func (it *Imterm) colorMode() ColorMode {
+	if cs, ok := it.screen.(ColorScreen); ok {
+		return cs.ColorMode()
+	}
+	return Colors8
+}
@@ -0,0 +1,83 @@
+package imterm
+
+import "testing"
+
+func TestColorEncoding(t *testing.T) {
+	for _, c := range []struct {
+		a       Attribute
+		r, g, b uint8
+		ok      bool
+	}{
+		{ColorDefault, 0, 0, 0, false},
+		{ColorRed, 205, 0, 0, true},
+		{ColorRed | AttrBold, 205, 0, 0, true},
+		{Color256(9), 255, 0, 0, true},
+		{Color256(16), 0, 0, 0, true},
+		{Color256(196), 255, 0, 0, true},
+		{Color256(232), 8, 8, 8, true},
+		{Color256(255), 238, 238, 238, true},
+		{RGB(1, 2, 3), 1, 2, 3, true},
+		{RGB(255, 255, 255) | AttrUnderline, 255, 255, 255, true},
+	} {
+		r, g, b, ok := c.a.RGB()
+		if r != c.r || g != c.g || b != c.b || ok != c.ok {
+			t.Errorf("%x: got %d %d %d %v, want %d %d %d %v", c.a, r, g, b, ok, c.r, c.g, c.b, c.ok)
+		}
+	}
+	if a := RGB(1, 2, 3) | AttrBold; a.Color() != RGB(1, 2, 3) || a.Attrs() != AttrBold {
+		t.Errorf("attributes mixed into the color of %x", a)
+	}
+	if Color256(1) == ColorBlack || Color256(1) == RGB(0, 0, 1) {
+		t.Error("palette colors clash with other colors")
+	}
+}
+
+func TestDownsample(t *testing.T) {
+	for _, c := range []struct {
+		a    Attribute
+		mode ColorMode
+		want Attribute
+	}{
+		{ColorRed, Colors8, ColorRed},
+		{ColorDefault | AttrBold, Colors8, ColorDefault | AttrBold},
+		{Color256(200), Colors256, Color256(200)},
+		{Color256(1), Colors8, ColorRed},
+		{Color256(9), Colors8, ColorRed},
+		{Color256(196) | AttrBold, Colors8, ColorRed | AttrBold},
+		{RGB(255, 0, 0), Colors256, Color256(9)},
+		{RGB(0, 0, 95), Colors256, Color256(17)},
+		{RGB(0, 0, 200), Colors8, ColorBlue},
+		{RGB(1, 2, 3), ColorsRGB, RGB(1, 2, 3)},
+	} {
+		if got := c.a.Downsample(c.mode); got != c.want {
+			t.Errorf("%x in mode %d: got %x, want %x", c.a, c.mode, got, c.want)
+		}
+	}
+}
+
+type colorScreen struct {
+	nullScreen
+	mode ColorMode
+	fg   Attribute
+}
+
+func (s *colorScreen) ColorMode() ColorMode { return s.mode }
+func (s *colorScreen) SetCell(x, y int, ch rune, fg, bg Attribute) {
+	if x == 0 && y == 0 {
+		s.fg = fg
+	}
+}
+
+func TestDownsampleCache(t *testing.T) {
+	s := &colorScreen{mode: Colors256}
+	it, _ := New(s)
+	for _, mode := range []ColorMode{Colors256, Colors8, Colors256} {
+		s.mode = mode
+		it.Start()
+		it.setCell(0, 0, 'x', RGB(255, 0, 0)|AttrBold, 0)
+		it.Finish()
+		if want := (RGB(255, 0, 0) | AttrBold).Downsample(mode); s.fg != want {
+			t.Errorf("mode %d: got %x, want %x", mode, s.fg, want)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package imterm
+
+type gridFrame struct {
+	flow       flow
+	cols, rows []int
+	x, y       int
+}
+
+// Start a grid filling the remaining width.  Each column and row track is a number of cells, a Pct of the
+// grid, or an Fr of what is left, with a Fit track counting as Fr(1), and the grid is only as tall as its rows.
+// Place items into the grid with GridCell or GridSpan.
+func (it *Imterm) BeginGrid(cols, rows []Size) {
+	w := (it.columnX + it.columnWidth) - it.xPos
+	h := it.bottom - it.yPos
+
+	gf := gridFrame{
+		cols: resolveSizes(w, cols),
+		rows: resolveSizes(h, rows),
+	}
+	gh := 0
+	for _, rh := range gf.rows {
+		gh += rh
+	}
+	b := it.getBox(Size(w), Size(gh))
+	gf.flow, gf.x, gf.y = it.flow, b.x, b.y
+
+	it.gridStack = append(it.gridStack, gf)
+	it.PushClip(0, 0, 0, 0)
+}
+
+// Place the following items in the given cell of the current grid
+func (it *Imterm) GridCell(col, row int) {
+	it.GridSpan(col, row, 1, 1)
+}
+
+// Place the following items in an area of the current grid, starting at the given cell and spanning cols columns
+// and rows rows
+func (it *Imterm) GridSpan(col, row, cols, rows int) {
+	gf := it.gridStack[len(it.gridStack)-1]
+	b := Box{gf.x, gf.y, 0, 0}
+	for i, cw := range gf.cols {
+		if i < col {
+			b.x += cw
+		} else if i < col+cols {
+			b.w += cw
+		}
+	}
+	for i, rh := range gf.rows {
+		if i < row {
+			b.y += rh
+		} else if i < row+rows {
+			b.h += rh
+		}
+	}
+
+	it.PopClip()
+	it.PushClip(b.x, b.y, b.w, b.h)
+	it.flow = flow{
+		xPos:        b.x,
+		yPos:        b.y,
+		columnX:     b.x,
+		columnY:     b.y,
+		columnWidth: b.w,
+		bottom:      b.y + b.h,
+	}
+}
+
+// Finish the current grid
+func (it *Imterm) EndGrid() {
+	it.PopClip()
+	gf := it.gridStack[len(it.gridStack)-1]
+	it.gridStack = it.gridStack[:len(it.gridStack)-1]
+	it.flow = gf.flow
+}
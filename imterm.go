@@ -1,12 +1,14 @@
 package imterm
 
 import (
+	"encoding/json"
 	"strings"
-
-	"github.com/mitchellh/go-wordwrap"
+	"unicode/utf8"
 )
 
-type Attribute uint16
+// Attribute is a color combined with any number of attributes.  Colors are one of the basic colors below, an entry
+// of the 256 color palette from Color256, or a true color from RGB.
+type Attribute uint64
 
 // Cell colors, you can combine a color with multiple attributes using bitwise
 // OR ('|').
@@ -30,10 +32,19 @@ const (
 // For example windows console doesn't support AttrUnderline. And on some
 // terminals applying AttrBold to background may result in blinking text. Use
 // them with caution and test your code on various terminals.
+// Screens draw attributes they can't show as the closest one they can, such as
+// a plain underline for AttrCurlyUnderline, or leave them out.
 const (
-	AttrBold Attribute = 1 << (iota + 9)
+	AttrBold Attribute = 1 << (iota + 32)
 	AttrUnderline
 	AttrReverse
+	AttrItalic
+	AttrDim
+	AttrStrikethrough
+	AttrBlink
+	AttrCurlyUnderline
+	AttrDoubleUnderline
+	AttrOverline
 )
 
 type Key uint16
@@ -64,6 +75,14 @@ const (
 	key_min // see terminfo
 )
 
+type Modifier uint8
+
+const (
+	ModAlt Modifier = 1 << iota
+	ModCtrl
+	ModShift
+)
+
 type MouseButton int
 
 const (
@@ -132,9 +151,25 @@ type Screen interface {
 	Clear(bg Attribute)
 }
 
+// Spacing is an amount of empty space on each side of a box
+type Spacing struct {
+	Top, Right, Bottom, Left int
+}
+
+// Even returns a Spacing of n cells on every side
+func Even(n int) Spacing {
+	return Spacing{n, n, n, n}
+}
+
+// Padding sits between a widget's border and its contents, Margin outside a widget's border.  HAlign and VAlign
+// place a widget's text within its contents.  Border picks the glyphs of a border class, BorderSingle if unset.
 type Style struct {
 	FgColor, BgColor Attribute
 	FgStyle, BgStyle Attribute
+
+	Padding, Margin Spacing
+	HAlign, VAlign  Align
+	Border          Border
 }
 
 func (s1 Style) Merge(s2 Style) Style {
@@ -150,6 +185,21 @@ func (s1 Style) Merge(s2 Style) Style {
 	if s1.BgStyle == 0 {
 		s1.BgStyle = s2.BgStyle
 	}
+	if s1.Padding == (Spacing{}) {
+		s1.Padding = s2.Padding
+	}
+	if s1.Margin == (Spacing{}) {
+		s1.Margin = s2.Margin
+	}
+	if s1.HAlign == 0 {
+		s1.HAlign = s2.HAlign
+	}
+	if s1.VAlign == 0 {
+		s1.VAlign = s2.VAlign
+	}
+	if s1.Border == (Border{}) {
+		s1.Border = s2.Border
+	}
 	return s1
 }
 
@@ -165,12 +215,16 @@ type InputState struct {
 
 	keyPress Key
 	chPress  rune
+	modPress Modifier
 }
 
 // Imterm is a simple immediate mode text ui library
-// Items are placed in a simple top down, left to right pattern
-// If an item's width is 0, it will resize to fill the remainder of the width
-// An item's ID must be unique, and by default is the label passed to the item.  This can be overriden by calling .ID() first.
+// Items are placed in a simple top down pattern, either on the screen or inside a Window, and can be
+// arranged side by side with BeginRow
+// If an item's width is 0, it will resize to fill the remainder of the width.  Sizes can also be given as a Pct
+// of the parent, an Fr of the remaining space, or Fit to size the item to its contents
+// An item's ID must be unique, and by default is the label passed to the item, scoped by any IDs pushed with
+// PushID, so "Delete" placed between PushID("row42") and PopID has the ID "row42/Delete".
 type Imterm struct {
 	screen Screen
 
@@ -178,14 +232,85 @@ type Imterm struct {
 	nextState InputState
 
 	mouseState MouseButton
+	pointerX   int
+	pointerY   int
+
+	flow
+
+	focusID  string
+	lastID   string
+	selected bool
+
+	focusOrder []string
+	focusMove  int
+	focusByKey bool
+
+	states      pseudoSet
+	statesKey   pseudoKey
+	statesValid bool
+
+	disabled      bool
+	disabledStack []bool
+	nextID        string
+	idStack       []string
 
+	TermW  int
+	TermH  int
+	mode   ColorMode
+	colors map[Attribute]Attribute
+
+	baseStyle  map[string]Style
+	styleStack []StyleAttr
+
+	widgetState map[string]interface{}
+	stateSeen   map[string]int
+	stateLoaded map[string]json.RawMessage
+	stateKeep   map[string]bool
+	frameNum    int
+
+	// Widget state and focus are dropped once an item hasn't been placed for this many frames, unless it was
+	// marked with KeepState.  0 keeps everything forever.
+	StateFrames int
+
+	// Read inline style tags, such as "[red::b]error[-]", in the text of widgets and frame labels.  Off by default,
+	// so text is shown exactly as given.
+	Markup bool
+
+	frLast, frNext   map[frKey]int
+	fitLast, fitNext map[layoutKey]int
+	layoutNum        int
+
+	lastBox Box
+
+	layer        *layer
+	layers       []*layer
+	lastLayers   []layer
+	hoverLayer   string
+	pointerLayer string
+	windowStack  []windowFrame
+	topZ         int
+
+	modalID      string
+	modalShown   bool
+	modalCapture bool
+	modalKeys    InputState
+	modalOpening string
+
+	tabStack  []tabFrame
+	focusSeen int
+
+	childStack  []childFrame
+	layoutStack []layoutFrame
+	gridStack   []gridFrame
+	clipStack   []Box
+	wheelUsed   bool
+}
+
+// flow tracks where the next item will be placed
+type flow struct {
 	xPos int
 	yPos int
 
-	focusID string
-	lastID  string
-	nextID  string
-
 	lastY int
 	nextX int
 	nextY int
@@ -196,34 +321,43 @@ type Imterm struct {
 	columnX     int
 	columnY     int
 	columnMaxY  int
+	maxX        int
 
-	TermW int
-	TermH int
+	bottom int
 
-	baseStyle  map[string]Style
-	styleStack []StyleAttr
-
-	widgetState map[string]interface{}
+	hAlign, vAlign Align
 
-	lastBox Box
+	line, lineFr int
 }
 
 func (it *Imterm) ClearState() {
 	for k := range it.widgetState {
 		delete(it.widgetState, k)
 	}
+	for k := range it.stateSeen {
+		delete(it.stateSeen, k)
+	}
+	for k := range it.stateLoaded {
+		delete(it.stateLoaded, k)
+	}
 }
 
 func (it *Imterm) getState(id string, def interface{}) interface{} {
+	it.seen(id)
 	if state, ok := it.widgetState[id]; ok {
 		return state
 	}
+	if raw, ok := it.stateLoaded[id]; ok {
+		delete(it.stateLoaded, id)
+		it.restoreState(raw, def)
+	}
 	it.widgetState[id] = def
 	return def
 }
 
 // set info about mouse actions
 func (it *Imterm) Mouse(x, y int, button MouseButton) {
+	it.pointerX, it.pointerY = x, y
 	if it.mouseState != button || button == MouseWheelUp || button == MouseWheelDown {
 		it.nextState.mouseX = x
 		it.nextState.mouseY = y
@@ -232,15 +366,28 @@ func (it *Imterm) Mouse(x, y int, button MouseButton) {
 	}
 }
 
+// Forget where the pointer is, so nothing is hovered until the next call to Mouse.  Backends that only report
+// the pointer moving while a button is held should call this once the buttons are released, or the last item
+// clicked would stay hovered.
+func (it *Imterm) ClearPointer() {
+	it.pointerX, it.pointerY = -1, -1
+}
+
 // Set info about keyboard presses.  Values are equivalent to termbox-go values
 func (it *Imterm) Keyboard(key Key, ch rune) {
+	it.KeyboardMod(key, ch, 0)
+}
+
+// Set info about keyboard presses, including which modifiers were held
+func (it *Imterm) KeyboardMod(key Key, ch rune, mod Modifier) {
 	it.nextState.keyPress = key
 	it.nextState.chPress = ch
+	it.nextState.modPress = mod
 }
 
 // Simple check what mouse button was clicked in a region
 func (it *Imterm) CheckClick(x, y, w, h int) MouseButton {
-	if it.curState.mouseButton != 0 {
+	if it.curState.mouseButton != 0 && !it.disabled && it.hoverLayer == it.layerID() && it.inClip(it.curState.mouseX, it.curState.mouseY) {
 		if it.curState.mouseX >= x && it.curState.mouseX < x+w &&
 			it.curState.mouseY >= y && it.curState.mouseY < y+h {
 			return it.curState.mouseButton
@@ -250,7 +397,7 @@ func (it *Imterm) CheckClick(x, y, w, h int) MouseButton {
 }
 
 func (it *Imterm) GetClick(x, y, w, h int) (mx, my int, mb MouseButton) {
-	if it.curState.mouseButton != 0 {
+	if it.curState.mouseButton != 0 && !it.disabled && it.hoverLayer == it.layerID() && it.inClip(it.curState.mouseX, it.curState.mouseY) {
 		if it.curState.mouseX >= x && it.curState.mouseX < x+w &&
 			it.curState.mouseY >= y && it.curState.mouseY < y+h {
 			return it.curState.mouseX - x, it.curState.mouseY - y, it.curState.mouseButton
@@ -261,49 +408,169 @@ func (it *Imterm) GetClick(x, y, w, h int) (mx, my int, mb MouseButton) {
 
 // Was the last object focused?
 func (it *Imterm) Focus() bool {
-	return it.focusID == it.lastID
+	return !it.disabled && it.focusID == it.lastID
 }
 
 func (it *Imterm) setLast(id string) {
 	it.lastID = id
+	if it.disabled && id == it.focusID {
+		it.focusID = ""
+	}
+	it.lastBox = Box{}
+	it.selected = false
+	it.seen(id)
+	if id == it.focusID {
+		it.focusSeen++
+	}
 }
 
 // Set the focus to a specific ID
 func (it *Imterm) SetFocus(id string) {
-	it.focusID = id
+	it.focusID, it.focusByKey = id, false
+}
+
+// tabStop adds the item being placed to the items Tab moves the focus through, unless it is disabled or sits
+// behind a modal
+func (it *Imterm) tabStop() {
+	if it.disabled || (it.modalCapture && it.modalID == "") {
+		return
+	}
+	it.focusOrder = append(it.focusOrder, it.lastID)
+}
+
+// moveFocus moves the focus to the next item placed this frame that takes keyboard input on Tab, or the one
+// before on Shift+Tab
+func (it *Imterm) moveFocus() {
+	n := len(it.focusOrder)
+	if it.focusMove == 0 || n == 0 {
+		return
+	}
+	next := 0
+	if it.focusMove < 0 {
+		next = n - 1
+	}
+	for i, id := range it.focusOrder {
+		if id == it.focusID {
+			next = (i + it.focusMove + n) % n
+			break
+		}
+	}
+	it.focusID, it.focusByKey = it.focusOrder[next], true
+}
+
+// Disable the following items until the matching EndDisabled if cond is set.  Disabled items are drawn with their
+// :disabled styles, ignore the mouse and keyboard, lose the focus and are skipped by Tab.  Nested calls stay
+// disabled if any of them are.
+func (it *Imterm) BeginDisabled(cond bool) {
+	it.disabledStack = append(it.disabledStack, it.disabled)
+	it.disabled = it.disabled || cond
+}
+
+// Finish the items disabled by the last BeginDisabled
+func (it *Imterm) EndDisabled() {
+	it.disabled = it.disabledStack[len(it.disabledStack)-1]
+	it.disabledStack = it.disabledStack[:len(it.disabledStack)-1]
 }
 
-// Override the next object to have the given ID
+// Override the next object to have the given ID, ignoring any IDs pushed with PushID
+//
+// Deprecated: use PushID and PopID
 func (it *Imterm) ID(id string) *Imterm {
 	it.nextID = id
 	return it
 }
 
+// Scope the IDs of the following items under id until the matching PopID.  Pushed IDs nest, so items placed
+// between PushID("list") and PushID("row42") have IDs starting with "list/row42/".
+func (it *Imterm) PushID(id string) {
+	it.idStack = append(it.idStack, it.scopedID(id)+"/")
+}
+
+// Remove the ID added by the last PushID
+func (it *Imterm) PopID() {
+	it.idStack = it.idStack[:len(it.idStack)-1]
+}
+
+func (it *Imterm) scopedID(id string) string {
+	if len(it.idStack) == 0 {
+		return id
+	}
+	return it.idStack[len(it.idStack)-1] + id
+}
+
 func (it *Imterm) getID(id string) (ret string) {
 	if it.nextID != "" {
 		ret, it.nextID = it.nextID, ""
 		return
 	}
-	ret = id
-	return
+	return it.scopedID(id)
 }
 
 type Box struct {
 	x, y, w, h int
 }
 
-func (it *Imterm) getBox(w, h int) (b Box) {
-	if w <= 0 {
-		w = ((it.columnX + it.columnWidth) - it.xPos) + w
+func (it *Imterm) getBox(w, h Size) Box {
+	return it.getFitBox(w, h, "", nil)
+}
+
+// getFitBox places an item, leaving room for the margin of class around it, and using measure to find its natural
+// size if asked to Fit
+func (it *Imterm) getFitBox(ws, hs Size, class string, measure measureFunc) (b Box) {
+	x, y := it.xPos, it.yPos
+	availW, availH := (it.columnX+it.columnWidth)-it.xPos, it.bottom-it.yPos
+
+	hkey := frKey{it.columnX, it.columnY, it.columnWidth, it.line, false}
+	vkey := frKey{it.columnX, it.columnY, it.columnWidth, 0, true}
+	newLine := it.xPos == it.columnX
+	if newLine {
+		it.line++
+		hkey.line = it.line
+		it.lineFr = it.frNext[vkey]
+	}
+	var m Spacing
+	if class != "" {
+		m = it.GetStyle(class).Margin
+	}
+	mw, mh := m.Left+m.Right, m.Top+m.Bottom
+
+	fitW, fitH := 0, 0
+	if measure != nil && (ws.measured(it.frLast[hkey]) || hs.measured(it.frLast[vkey])) {
+		fitW, fitH = measure(availW - mw)
+		fitW, fitH = fitW+mw, fitH+mh
+	}
+	w := resolveSize(ws, availW, it.columnWidth, fitW, it.frNext[hkey], it.frLast[hkey])
+	if ws > 0 && ws.unit() == unitCells {
+		w += mw
+	}
+	if measure != nil && hs.measured(it.frLast[vkey]) && w != fitW {
+		_, fitH = measure(w - mw)
+		fitH += mh
+	}
+	h := resolveSize(hs, availH, it.bottom-it.columnY, fitH, it.lineFr, it.frLast[vkey])
+	if hs > 0 && hs.unit() == unitCells {
+		h += mh
+	}
+	if ws.unit() == unitFr {
+		it.frNext[hkey] += ws.value()
 	}
-	if h <= 0 {
-		h = (it.TermH - it.yPos) + h
+	if hs.unit() == unitFr && newLine {
+		it.frNext[vkey] += hs.value()
 	}
-	b = Box{it.xPos, it.yPos, w, h}
+	if ws > 0 {
+		x, w = align(it.hAlign, x, w, availW)
+	}
+	if hs > 0 {
+		y, h = align(it.vAlign, y, h, availH)
+	}
+	b = Box{x, y, w, h}.inset(m)
 	it.lastBox = b
-	it.nextX = it.xPos + w
+	it.nextX = x + w
+	if it.maxX < it.nextX {
+		it.maxX = it.nextX
+	}
 	it.lastY = it.yPos
-	it.xPos, it.yPos = it.columnX, it.yPos+h
+	it.xPos, it.yPos = it.columnX, y+h
 	if it.yPos < it.nextY {
 		it.yPos = it.nextY
 	}
@@ -313,12 +580,14 @@ func (it *Imterm) getBox(w, h int) (b Box) {
 	return
 }
 
+// Deprecated: use BeginRow
 func (it *Imterm) StartColumns(w int) {
 	it.columnStack = append(it.columnStack, struct{ x, y, maxy, w int }{it.columnX, it.columnY, it.columnMaxY, it.columnWidth})
 	it.columnY = it.yPos
 	it.columnWidth = w
 }
 
+// Deprecated: use BeginRow and Next
 func (it *Imterm) NextColumn(w int) {
 	it.columnX = it.columnX + it.columnWidth
 	if w == 0 {
@@ -330,63 +599,161 @@ func (it *Imterm) NextColumn(w int) {
 	it.nextY = it.yPos
 }
 
+// Deprecated: use BeginRow and EndRow
 func (it *Imterm) FinishColumns() {
 	it.yPos = it.columnMaxY
 	it.columnX, it.columnY, it.columnMaxY, it.columnWidth = it.columnStack[len(it.columnStack)-1].x, it.columnStack[len(it.columnStack)-1].y, it.columnStack[len(it.columnStack)-1].maxy, it.columnStack[len(it.columnStack)-1].w
 	it.columnStack = it.columnStack[:len(it.columnStack)-1]
 	it.xPos = it.columnX
+	if it.columnMaxY < it.yPos {
+		it.columnMaxY = it.yPos
+	}
+}
+
+// pseudoSet holds the pseudo-states an item is in, most specific first
+type pseudoSet struct {
+	names [5]string
+	n     int
+}
+
+func (ps *pseudoSet) add(name string) {
+	ps.names[ps.n] = name
+	ps.n++
 }
 
+// pseudoKey is what the pseudo-states depend on that changes between the items of a frame
+type pseudoKey struct {
+	id, focus          string
+	box, clip          Box
+	layer              *layer
+	disabled, selected bool
+}
+
+// pseudoStates lists the pseudo-states the item being placed is in, most specific first.  They are only worked
+// out again once something they depend on changes, so usually once per item.
+func (it *Imterm) pseudoStates() []string {
+	key := pseudoKey{id: it.lastID, focus: it.focusID, box: it.lastBox, layer: it.layer, disabled: it.disabled,
+		selected: it.selected}
+	if len(it.clipStack) > 0 {
+		key.clip = it.clipStack[len(it.clipStack)-1]
+	}
+	if it.statesValid && key == it.statesKey {
+		return it.states.names[:it.states.n]
+	}
+	it.states, it.statesKey, it.statesValid = pseudoSet{}, key, true
+	if it.disabled {
+		it.states.add("disabled")
+	}
+	hover := it.pointerLayer == it.layerID() && it.lastBox.contains(it.pointerX, it.pointerY) &&
+		it.inClip(it.pointerX, it.pointerY)
+	if hover && it.mouseState == MouseLeft {
+		it.states.add("active")
+	}
+	if it.selected {
+		it.states.add("selected")
+	}
+	if hover {
+		it.states.add("hover")
+	}
+	if it.Focus() {
+		it.states.add("focus")
+	}
+	return it.states.names[:it.states.n]
+}
+
+// rowStyle returns the style of class for one row of the item being placed, so that :hover and :active follow
+// the row under the pointer rather than the whole item
+func (it *Imterm) rowStyle(class string, row Box) CalcedStyle {
+	box := it.lastBox
+	it.lastBox = row
+	s := it.GetStyle(class)
+	it.lastBox = box
+	return s
+}
+
+// Returns the base style of a class.  A class such as "text.border" falls back on "border", and each can be
+// refined for the pseudo-states of the item being placed, in order of precedence "text.border:disabled",
+// ":active" while the mouse is held on it, ":selected", ":hover" while the pointer is over it and ":focus".  The
+// rows of lists and radio groups are hovered one at a time.  Hover is only as good as the pointer positions
+// passed to Mouse, see ClearPointer.
 func (it *Imterm) GetBaseStyle(name string) Style {
+	return it.baseStyleFor(name, it.pseudoStates())
+}
+
+func (it *Imterm) baseStyleFor(name string, states []string) Style {
 	val := Style{}
-	if it.Focus() {
-		nval, ok := it.baseStyle[name+":focus"]
-		if ok {
+	merge := func(name string) {
+		for _, state := range states {
+			if nval, ok := it.baseStyle[name+":"+state]; ok {
+				val = val.Merge(nval)
+			}
+		}
+		if nval, ok := it.baseStyle[name]; ok {
 			val = val.Merge(nval)
 		}
 	}
-	nval, ok := it.baseStyle[name]
-	if ok {
-		val = val.Merge(nval)
-	}
-	val = val.Merge(nval)
+	merge(name)
 	split := strings.SplitAfter(name, ".")
 	splitlen := 0
 	for _, s := range split {
 		splitlen += len(s)
-		partname := name[splitlen:]
-		if it.Focus() {
-			nval, ok = it.baseStyle[partname+":focus"]
-			if ok {
-				val = val.Merge(nval)
-			}
+		merge(name[splitlen:])
+	}
+	return val
+}
+
+// hasStateStyle reports whether the theme or style stack refines class for the pseudo-state state
+func (it *Imterm) hasStateStyle(name, state string) bool {
+	for _, s := range it.styleStack {
+		if s.Name == name+":"+state || strings.HasSuffix(s.Name, "."+name+":"+state) {
+			return true
 		}
-		nval, ok := it.baseStyle[partname]
-		if ok {
-			val = val.Merge(nval)
+	}
+	if _, ok := it.baseStyle[name+":"+state]; ok {
+		return true
+	}
+	splitlen := 0
+	for _, s := range strings.SplitAfter(name, ".") {
+		splitlen += len(s)
+		if _, ok := it.baseStyle[name[splitlen:]+":"+state]; ok {
+			return true
 		}
-
 	}
-	return val
+	return false
 }
 
 type CalcedStyle struct {
 	Fg, Bg Attribute
+
+	Padding, Margin Spacing
+	HAlign, VAlign  Align
+	Border          Border
 }
 
 func (it *Imterm) GetStyle(name string) CalcedStyle {
-	val := it.GetBaseStyle(name)
+	states := it.pseudoStates()
+	val := it.baseStyleFor(name, states)
 	for _, s := range it.styleStack {
 		if s.Name == name || strings.HasSuffix(s.Name, "."+name) {
 			val = s.Value.Merge(val)
 		}
-		if it.Focus() && (s.Name == name+":focus" || strings.HasSuffix(s.Name, "."+name+":focus")) {
-			val = s.Value.Merge(val)
+		for i := len(states) - 1; i >= 0; i-- {
+			if s.Name == name+":"+states[i] || strings.HasSuffix(s.Name, "."+name+":"+states[i]) {
+				val = s.Value.Merge(val)
+			}
 		}
 	}
+	if val.Border == (Border{}) {
+		val.Border = BorderSingle
+	}
 	return CalcedStyle{
-		Fg: val.FgColor | val.FgStyle,
-		Bg: val.BgColor | val.BgStyle,
+		Fg:      val.FgColor | val.FgStyle,
+		Bg:      val.BgColor | val.BgStyle,
+		Padding: val.Padding,
+		Margin:  val.Margin,
+		HAlign:  val.HAlign,
+		VAlign:  val.VAlign,
+		Border:  val.Border,
 	}
 }
 
@@ -400,15 +767,19 @@ func (it *Imterm) PopStyles(num int) {
 
 func New(screen Screen) (*Imterm, error) {
 	it := &Imterm{
-		screen: screen,
-		baseStyle: map[string]Style{
-			"border:focus":       Style{FgStyle: AttrBold},
-			"border.label:focus": Style{FgStyle: AttrBold},
-			"active.border":      Style{FgColor: ColorGreen},
-			"gauge.bar.on":       Style{BgColor: ColorRed},
-		},
+		screen:      screen,
 		widgetState: map[string]interface{}{},
+		stateSeen:   map[string]int{},
+		stateLoaded: map[string]json.RawMessage{},
+		stateKeep:   map[string]bool{},
+		StateFrames: DefaultStateFrames,
+		frLast:      map[frKey]int{},
+		frNext:      map[frKey]int{},
+		fitLast:     map[layoutKey]int{},
+		fitNext:     map[layoutKey]int{},
 	}
+	it.SetTheme("default")
+	it.ClearPointer()
 	it.TermW, it.TermH = screen.Size()
 	return it, nil
 }
@@ -416,54 +787,180 @@ func New(screen Screen) (*Imterm, error) {
 // Start a frame, this must be called before drawing any objects to the screen
 func (it *Imterm) Start() {
 	it.TermW, it.TermH = it.screen.Size()
-	it.xPos, it.yPos = 0, 0
-	it.nextX, it.nextY, it.lastY = 0, 0, 0
-	it.screen.Clear(it.GetStyle("").Bg)
+	if mode := it.colorMode(); mode != it.mode || it.colors == nil {
+		it.mode, it.colors = mode, map[Attribute]Attribute{}
+	}
+	it.screen.Clear(it.downsample(it.GetStyle("").Bg))
 	it.curState = it.nextState
 	it.nextState = InputState{}
+	it.frameNum++
+	it.statesValid = false
+	it.focusOrder, it.focusMove = it.focusOrder[:0], 0
+	if it.curState.keyPress == KeyTab && it.curState.chPress == 0 {
+		it.focusMove = 1
+		if it.curState.modPress&ModShift != 0 {
+			it.focusMove = -1
+		}
+	}
+
+	it.flow = flow{
+		columnStack: it.columnStack[:0],
+		columnWidth: it.TermW,
+		bottom:      it.TermH,
+	}
+	it.idStack = it.idStack[:0]
+	it.disabled, it.disabledStack = false, it.disabledStack[:0]
+	it.tabStack = it.tabStack[:0]
+	it.childStack = it.childStack[:0]
+	it.layoutStack = it.layoutStack[:0]
+	it.gridStack = it.gridStack[:0]
+	it.clipStack = append(it.clipStack[:0], it.screenBox())
+	it.wheelUsed = false
+	it.frLast, it.frNext = it.frNext, it.frLast
+	for k := range it.frNext {
+		delete(it.frNext, k)
+	}
+	it.fitLast, it.fitNext = it.fitNext, it.fitLast
+	for k := range it.fitNext {
+		delete(it.fitNext, k)
+	}
+	it.layoutNum = 0
+
+	it.startLayers()
+	it.startModal()
+}
+
+func (s Spacing) sum(o Spacing) Spacing {
+	return Spacing{s.Top + o.Top, s.Right + o.Right, s.Bottom + o.Bottom, s.Left + o.Left}
+}
+
+// size returns the width and height s adds to a box
+func (s Spacing) size() (w, h int) {
+	return s.Left + s.Right, s.Top + s.Bottom
+}
+
+func (b Box) inset(s Spacing) Box {
+	return Box{b.x + s.Left, b.y + s.Top, b.w - s.Left - s.Right, b.h - s.Top - s.Bottom}
+}
+
+func (b Box) intersect(o Box) Box {
+	x, y := b.x, b.y
+	if o.x > x {
+		x = o.x
+	}
+	if o.y > y {
+		y = o.y
+	}
+	x2, y2 := b.x+b.w, b.y+b.h
+	if o.x+o.w < x2 {
+		x2 = o.x + o.w
+	}
+	if o.y+o.h < y2 {
+		y2 = o.y + o.h
+	}
+	if x2 < x {
+		x2 = x
+	}
+	if y2 < y {
+		y2 = y
+	}
+	return Box{x, y, x2 - x, y2 - y}
+}
+
+func (b Box) contains(x, y int) bool {
+	return x >= b.x && x < b.x+b.w && y >= b.y && y < b.y+b.h
+}
+
+// Restrict drawing and clicks to the given region until the matching PopClip.  Nested regions are intersected.
+func (it *Imterm) PushClip(x, y, w, h int) {
+	b := Box{x, y, w, h}
+	if len(it.clipStack) > 0 {
+		b = b.intersect(it.clipStack[len(it.clipStack)-1])
+	}
+	it.clipStack = append(it.clipStack, b)
+}
+
+// Remove the region added by the last PushClip
+func (it *Imterm) PopClip() {
+	it.clipStack = it.clipStack[:len(it.clipStack)-1]
+}
+
+func (it *Imterm) screenBox() Box {
+	return Box{0, 0, it.TermW, it.TermH}
+}
 
-	it.columnX, it.columnY = 0, 0
-	it.columnMaxY = 0
-	it.columnWidth = it.TermW
-	it.columnStack = it.columnStack[:0]
+func (it *Imterm) inClip(x, y int) bool {
+	return len(it.clipStack) == 0 || it.clipStack[len(it.clipStack)-1].contains(x, y)
 }
 
-func (it *Imterm) hLine(x, y int, w int, s CalcedStyle) {
+func (it *Imterm) setCell(x, y int, ch rune, fg, bg Attribute) {
+	if !it.inClip(x, y) {
+		return
+	}
+	if it.layer != nil {
+		it.layer.cells = append(it.layer.cells, layerCell{x, y, Cell{ch, fg, bg}})
+		return
+	}
+	it.screen.SetCell(x, y, ch, it.downsample(fg), it.downsample(bg))
+}
+
+func (it *Imterm) hLine(x, y int, w int, ch rune, s CalcedStyle) {
 	for i := 0; i <= w; i++ {
-		it.screen.SetCell(x+i, y, '─', s.Fg, s.Bg)
+		it.setCell(x+i, y, ch, s.Fg, s.Bg)
 	}
 }
 
-func (it *Imterm) vLine(x, y int, h int, s CalcedStyle) {
+func (it *Imterm) vLine(x, y int, h int, ch rune, s CalcedStyle) {
 	for i := 0; i <= h; i++ {
-		it.screen.SetCell(x, y+i, '│', s.Fg, s.Bg)
+		it.setCell(x, y+i, ch, s.Fg, s.Bg)
 	}
 }
 
-func (it *Imterm) frame(b Box, label string, class string) {
+// frame draws the border of class around b and returns the box left inside it.  Without a border, the label
+// is drawn on the top row instead.
+func (it *Imterm) frame(b Box, label string, class string) Box {
 	s := it.GetStyle(class)
+	bd := s.Border
+	if bd.none() {
+		if label == "" {
+			return b
+		}
+		ls := it.GetStyle(class + ".label")
+		cells := it.cells(label, ls.Fg, ls.Bg)
+		n := len(cells)
+		if n > b.w {
+			n = b.w
+		}
+		lx, _ := align(ls.HAlign, b.x, n, b.w)
+		it.cellRow(lx, b.y, n, cells)
+		return b.inset(Spacing{Top: 1})
+	}
 	x, y, w, h := b.x, b.y, b.w, b.h
 
-	it.hLine(x+1, y, w-3, s)
-	it.hLine(x+1, y+h-1, w-3, s)
-	it.vLine(x, y+1, h-3, s)
-	it.vLine(x+w-1, y+1, h-3, s)
-	it.screen.SetCell(x, y, '┌', s.Fg, s.Bg)
-	it.screen.SetCell(x+w-1, y, '┐', s.Fg, s.Bg)
-	it.screen.SetCell(x, y+h-1, '└', s.Fg, s.Bg)
-	it.screen.SetCell(x+w-1, y+h-1, '┘', s.Fg, s.Bg)
+	it.hLine(x+1, y, w-3, bd.Horizontal, s)
+	it.hLine(x+1, y+h-1, w-3, bd.Horizontal, s)
+	it.vLine(x, y+1, h-3, bd.Vertical, s)
+	it.vLine(x+w-1, y+1, h-3, bd.Vertical, s)
+	it.setCell(x, y, bd.TopLeft, s.Fg, s.Bg)
+	it.setCell(x+w-1, y, bd.TopRight, s.Fg, s.Bg)
+	it.setCell(x, y+h-1, bd.BottomLeft, s.Fg, s.Bg)
+	it.setCell(x+w-1, y+h-1, bd.BottomRight, s.Fg, s.Bg)
 	if label != "" {
-		it.screen.SetCell(x+1, y, '◄', s.Fg, s.Bg)
 		ls := it.GetStyle(class + ".label")
-		var i int
-		for i = 0; i < w-4; i++ {
-			if i >= len(label) {
-				break
-			}
-			it.screen.SetCell(x+i+2, y, rune(label[i]), ls.Fg, ls.Bg)
+		cells := it.cells(label, ls.Fg, ls.Bg)
+		n := len(cells)
+		if n > w-4 {
+			n = w - 4
+		}
+		if n < 0 {
+			n = 0
 		}
-		it.screen.SetCell(x+i+2, y, '►', s.Fg, s.Bg)
+		lx, _ := align(ls.HAlign, x+1, n+2, w-2)
+		it.setCell(lx, y, bd.LabelLeft, s.Fg, s.Bg)
+		it.cellRow(lx+1, y, n, cells)
+		it.setCell(lx+n+1, y, bd.LabelRight, s.Fg, s.Bg)
 	}
+	return Box{x + 1, y + 1, w - 2, h - 2}
 }
 
 type textState struct {
@@ -475,20 +972,26 @@ func (it *Imterm) GetLast() (x, y, w, h int) {
 }
 
 // Place a text label.  Not editable
-func (it *Imterm) Text(w, h int, label string, text string) {
+func (it *Imterm) Text(width, height Size, label string, text string) {
 	id := it.getID(label)
 	it.setLast(id)
-	b := it.getBox(w, h)
+	b := it.getFitBox(width, height, "text", measureWrapped(it.plain(text), it.GetStyle("text.text").Padding.sum(it.frameInset("text.border", label))))
+	it.PushClip(b.x, b.y, b.w, b.h)
+	defer it.PopClip()
 
-	it.frame(b, label, "text.border")
+	in := it.frame(b, label, "text.border")
+	bd := it.GetStyle("text.border").Border
+	arrows := !bd.none()
 
 	s := it.GetStyle("text.text")
 
 	state := it.getState(id, &textState{}).(*textState)
 
-	it.screen.SetCell(b.x+b.w-1, b.y+1, '▲', s.Fg, s.Bg)
+	if arrows {
+		it.setCell(b.x+b.w-1, b.y+1, bd.Up, s.Fg, s.Bg)
+	}
 	if state.scroll > 0 {
-		if it.CheckClick(b.x+b.w-1, b.y+1, 1, 1) == MouseLeft {
+		if arrows && it.CheckClick(b.x+b.w-1, b.y+1, 1, 1) == MouseLeft {
 			state.scroll--
 		}
 		if it.CheckClick(b.x, b.y, b.w, b.h) == MouseWheelUp {
@@ -496,27 +999,19 @@ func (it *Imterm) Text(w, h int, label string, text string) {
 		}
 	}
 
-	wrapped := wordwrap.WrapString(text, uint(b.w-2))
-	cx, cy := 0, 0
-
-	more := false
-	for _, r := range wrapped {
-		if r == '\n' {
-			cx, cy = 0, cy+1
-			if cy-state.scroll >= b.h-2 {
-				more = true
-				break
-			}
-		} else {
-			if cy-state.scroll >= 0 {
-				it.screen.SetCell(b.x+1+cx, b.y+1+cy-state.scroll, r, s.Fg, s.Bg)
-			}
-			cx++
-		}
+	inner := in.inset(s.Padding)
+	lines := wrapCells(it.cells(text, s.Fg, s.Bg), inner.w)
+	more := len(lines)-state.scroll > inner.h
+	top, _ := align(s.VAlign, inner.y, len(lines), inner.h)
+	for i := state.scroll; i < len(lines) && i-state.scroll < inner.h; i++ {
+		lx, _ := align(s.HAlign, inner.x, len(lines[i]), inner.w)
+		it.cellRow(lx, top+i-state.scroll, inner.x+inner.w-lx, lines[i])
+	}
+	if arrows {
+		it.setCell(b.x+b.w-1, b.y+b.h-2, bd.Down, s.Fg, s.Bg)
 	}
-	it.screen.SetCell(b.x+b.w-1, b.y+b.h-2, '▼', s.Fg, s.Bg)
 	if more {
-		if it.CheckClick(b.x+b.w-1, b.y+b.h-2, 1, 1) == MouseLeft {
+		if arrows && it.CheckClick(b.x+b.w-1, b.y+b.h-2, 1, 1) == MouseLeft {
 			state.scroll++
 		}
 		if it.CheckClick(b.x, b.y, b.w, b.h) == MouseWheelDown {
@@ -535,72 +1030,89 @@ type bufferState struct {
 }
 
 // Place a buffer.  Not editable, but responds to click events.  Expects buffer to be uniform in size for all rows.
-func (it *Imterm) Buffer(w, h int, label string, buffer [][]Cell) (mx, my int, mb MouseButton) {
+func (it *Imterm) Buffer(width, height Size, label string, buffer [][]Cell) (mx, my int, mb MouseButton) {
 	id := it.getID(label)
 	it.setLast(id)
-	b := it.getBox(w, h)
+	bw := 0
+	if len(buffer) > 0 {
+		bw = len(buffer[0])
+	}
+	fw, fh := it.frameInset("text.border", label).size()
+	b := it.getFitBox(width, height, "buffer", fixedSize(bw+fw, len(buffer)+fh))
+	it.PushClip(b.x, b.y, b.w, b.h)
+	defer it.PopClip()
 
-	it.frame(b, label, "text.border")
+	in := it.frame(b, label, "text.border")
+	bd := it.GetStyle("text.border").Border
+	arrows := !bd.none()
 
 	s := it.GetStyle("text.text")
 
 	state := it.getState(id, &bufferState{}).(*bufferState)
-	it.screen.SetCell(b.x+b.w-1, b.y+1, '▲', s.Fg, s.Bg)
+	if arrows {
+		it.setCell(b.x+b.w-1, b.y+1, bd.Up, s.Fg, s.Bg)
+	}
 	if state.yscroll > 0 {
-		if it.CheckClick(b.x+b.w-1, b.y+1, 1, 1) == MouseLeft {
+		if arrows && it.CheckClick(b.x+b.w-1, b.y+1, 1, 1) == MouseLeft {
 			state.yscroll--
 		}
 		if it.CheckClick(b.x, b.y, b.w, b.h) == MouseWheelUp {
 			state.yscroll--
 		}
 	}
-	it.screen.SetCell(b.x+b.w-1, b.y+b.h-2, '▼', s.Fg, s.Bg)
-	if state.yscroll+b.h-2 < len(buffer) {
-		if it.CheckClick(b.x+b.w-1, b.y+b.h-2, 1, 1) == MouseLeft {
+	if arrows {
+		it.setCell(b.x+b.w-1, b.y+b.h-2, bd.Down, s.Fg, s.Bg)
+	}
+	if state.yscroll+in.h < len(buffer) {
+		if arrows && it.CheckClick(b.x+b.w-1, b.y+b.h-2, 1, 1) == MouseLeft {
 			state.yscroll++
 		}
 		if it.CheckClick(b.x, b.y, b.w, b.h) == MouseWheelDown {
 			state.yscroll++
 		}
-	} else if state.yscroll+b.h-2 > len(buffer) {
-		state.yscroll = len(buffer) - b.h - 2
+	} else if state.yscroll+in.h > len(buffer) {
+		state.yscroll = len(buffer) - in.h
 		if state.yscroll < 0 {
 			state.yscroll = 0
 		}
 	}
 
-	it.screen.SetCell(b.x+1, b.y+b.h-1, '◄', s.Fg, s.Bg)
+	if arrows {
+		it.setCell(b.x+1, b.y+b.h-1, bd.Left, s.Fg, s.Bg)
+	}
 	if state.xscroll > 0 {
-		if it.CheckClick(b.x+1, b.y+b.h-1, 1, 1) == MouseLeft {
+		if arrows && it.CheckClick(b.x+1, b.y+b.h-1, 1, 1) == MouseLeft {
 			state.xscroll--
 		}
 	}
-	it.screen.SetCell(b.x+b.w-2, b.y+b.h-1, '►', s.Fg, s.Bg)
-	if state.xscroll+b.w-2 < len(buffer[0]) {
-		if it.CheckClick(b.x+b.w-2, b.y+b.h-1, 1, 1) == MouseLeft {
+	if arrows {
+		it.setCell(b.x+b.w-2, b.y+b.h-1, bd.Right, s.Fg, s.Bg)
+	}
+	if state.xscroll+in.w < bw {
+		if arrows && it.CheckClick(b.x+b.w-2, b.y+b.h-1, 1, 1) == MouseLeft {
 			state.xscroll++
 		}
-	} else if state.xscroll+b.w-2 > len(buffer[0]) {
-		state.xscroll = len(buffer[0]) - b.w - 2
+	} else if state.xscroll+in.w > bw {
+		state.xscroll = bw - in.w
 		if state.xscroll < 0 {
 			state.xscroll = 0
 		}
 	}
 
-	for cy := 0; cy < b.h-2; cy++ {
+	for cy := 0; cy < in.h; cy++ {
 		if cy+state.yscroll >= len(buffer) {
 			break
 		}
 		row := buffer[cy+state.yscroll]
-		for cx := 0; cx < b.w-2; cx++ {
+		for cx := 0; cx < in.w; cx++ {
 			if cx+state.xscroll >= len(row) {
 				break
 			}
 			cell := row[cx+state.xscroll]
-			it.screen.SetCell(b.x+1+cx, b.y+1+cy, cell.Char, cell.Fg, cell.Bg)
+			it.setCell(in.x+cx, in.y+cy, cell.Char, cell.Fg, cell.Bg)
 		}
 	}
-	return it.GetClick(b.x+1, b.y+1, b.w-2, b.h-2)
+	return it.GetClick(in.x, in.y, in.w, in.h)
 }
 
 type inputState struct {
@@ -608,11 +1120,14 @@ type inputState struct {
 }
 
 // Place an editable text area
-func (it *Imterm) Input(w, h int, label string, text string) string {
+func (it *Imterm) Input(width, height Size, label string, text string) string {
 	id := it.getID(label)
 	it.setLast(id)
-	b := it.getBox(w, h)
-	x, y, w, h := b.x, b.y, b.w, b.h
+	it.tabStop()
+	fi := it.frameInset("input.border", label)
+	b := it.getFitBox(width, height, "input", measureWrapped(text, fi.sum(Spacing{Right: 1})))
+	it.PushClip(b.x, b.y, b.w, b.h)
+	defer it.PopClip()
 
 	state := it.getState(id, &inputState{cPos: -1}).(*inputState)
 	mx, my := -1, -1
@@ -621,20 +1136,22 @@ func (it *Imterm) Input(w, h int, label string, text string) string {
 		state.cPos = len(text)
 	}
 
-	if it.CheckClick(x, y, w, h) == MouseLeft {
-		mx = it.curState.mouseX - (x + 1)
-		my = it.curState.mouseY - (y + 1)
+	in := b.inset(fi)
+	x, y, w, h := in.x, in.y, in.w, in.h
+	if it.CheckClick(b.x, b.y, b.w, b.h) == MouseLeft {
+		mx = it.curState.mouseX - x
+		my = it.curState.mouseY - y
 		if mx < 0 {
 			mx = 0
-		} else if mx > w-2 {
-			mx = w - 2
+		} else if mx > w {
+			mx = w
 		}
 		if my < 0 {
 			my = 0
-		} else if my > h-2 {
-			my = h - 2
+		} else if my > h {
+			my = h
 		}
-		it.focusID = id
+		it.SetFocus(id)
 	}
 
 	if it.Focus() {
@@ -695,7 +1212,7 @@ func (it *Imterm) Input(w, h int, label string, text string) string {
 		}
 		if r == '\n' {
 			if i == state.cPos && showcursor {
-				it.screen.SetCell(cx+x+1, cy+y+1, ' ', s.Fg|AttrUnderline, s.Bg|AttrUnderline)
+				it.setCell(cx+x, cy+y, ' ', s.Fg|AttrUnderline, s.Bg|AttrUnderline)
 				cursor = true
 			}
 
@@ -704,7 +1221,7 @@ func (it *Imterm) Input(w, h int, label string, text string) string {
 				state.cPos = i
 				mx = -1
 			}
-			if cy >= h-2 {
+			if cy >= h {
 				break
 			}
 		} else {
@@ -713,29 +1230,29 @@ func (it *Imterm) Input(w, h int, label string, text string) string {
 				if nextspace == -1 {
 					nextspace = len(text[i:])
 				}
-				if nextspace > (w-2)-cx {
+				if nextspace > w-cx {
 					cx, cy = 0, cy+1
 					if mx >= 0 && cy > my {
 						state.cPos = i
 						mx = -1
 					}
-					if cy >= h-2 {
+					if cy >= h {
 						break
 					}
 				}
 				nextspace += i
 			}
-			if cx >= w-2 {
+			if cx >= w {
 				cx, cy = 0, cy+1
-				if cy >= h-2 {
+				if cy >= h {
 					break
 				}
 			}
 			if i == state.cPos && showcursor {
-				it.screen.SetCell(cx+x+1, cy+y+1, r, s.Fg|AttrUnderline, s.Bg|AttrUnderline)
+				it.setCell(cx+x, cy+y, r, s.Fg|AttrUnderline, s.Bg|AttrUnderline)
 				cursor = true
 			} else {
-				it.screen.SetCell(cx+x+1, cy+y+1, r, s.Fg, s.Bg)
+				it.setCell(cx+x, cy+y, r, s.Fg, s.Bg)
 			}
 			cx++
 		}
@@ -744,58 +1261,52 @@ func (it *Imterm) Input(w, h int, label string, text string) string {
 		state.cPos = len(text)
 		mx = -1
 	}
-	if !cursor && cy < h-2 && showcursor {
-		it.screen.SetCell(cx+x+1, cy+y+1, ' ', s.Fg|AttrUnderline, s.Bg|AttrUnderline)
+	if !cursor && cy < h && showcursor {
+		it.setCell(cx+x, cy+y, ' ', s.Fg|AttrUnderline, s.Bg|AttrUnderline)
 	}
 	return text
 }
 
-// Place a clickable button
-func (it *Imterm) Button(w, h int, label string) bool {
+// Place a clickable button, which can also be pressed with Enter or Space once Tab has given it the focus
+func (it *Imterm) Button(width, height Size, label string) bool {
 	id := it.getID(label)
 	it.setLast(id)
-	b := it.getBox(w, h)
-	x, y, w, h := b.x, b.y, b.w, b.h
+	it.tabStop()
+	b := it.getFitBox(width, height, "button", measureWrapped(it.plain(label), it.GetStyle("button.text").Padding.sum(it.frameInset("button.border", ""))))
+	it.PushClip(b.x, b.y, b.w, b.h)
+	defer it.PopClip()
 
 	click := false
 
-	if it.CheckClick(x, y, w, h) == MouseLeft {
+	if it.CheckClick(b.x, b.y, b.w, b.h) == MouseLeft {
 		it.SetFocus(id)
 		click = true
+	} else if it.Focus() && it.activated() {
+		click = true
 	}
 
-	it.frame(b, "", "button.border")
-	s := it.GetStyle("button.text")
-	wrapped := wordwrap.WrapString(label, uint(w-2))
-	cx, cy := x+1, y+1
-
-	for _, r := range wrapped {
-		if r == '\n' {
-			cx, cy = x+1, cy+1
-			if cy >= y+h {
-				break
-			}
-		} else {
-			it.screen.SetCell(cx, cy, r, s.Fg, s.Bg)
-			cx++
-		}
-	}
+	in := it.frame(b, "", "button.border")
+	it.label(in, label, "button.text")
 
 	return click
 }
 
-// Place a toggleable button
-func (it *Imterm) Toggle(w, h int, label string, state bool) bool {
+// Place a toggleable button, which can also be toggled with Enter or Space once Tab has given it the focus
+func (it *Imterm) Toggle(width, height Size, label string, state bool) bool {
 	id := it.getID(label)
 	it.setLast(id)
-	b := it.getBox(w, h)
-	x, y, w, h := b.x, b.y, b.w, b.h
+	it.tabStop()
+	b := it.getFitBox(width, height, "toggle", measureWrapped(it.plain(label), it.GetStyle("toggle.text").Padding.sum(it.frameInset("toggle.border", ""))))
+	it.PushClip(b.x, b.y, b.w, b.h)
+	defer it.PopClip()
 
 	click := false
 
-	if it.CheckClick(x, y, w, h) == MouseLeft {
+	if it.CheckClick(b.x, b.y, b.w, b.h) == MouseLeft {
 		it.SetFocus(id)
 		click = true
+	} else if it.Focus() && it.activated() {
+		click = true
 	}
 
 	if click {
@@ -806,50 +1317,196 @@ func (it *Imterm) Toggle(w, h int, label string, state bool) bool {
 	if state {
 		class += ".active"
 	}
+	it.selected = state
+
+	in := it.frame(b, "", class+".border")
+	it.label(in, label, class+".text")
+
+	return state
+}
+
+// activated reports whether the key pressed this frame activates the focused item.  Only focus given by Tab
+// counts, so that keys pressed after clicking an item don't activate it a second time.
+func (it *Imterm) activated() bool {
+	return it.focusByKey && (it.curState.keyPress == KeySpace || it.curState.keyPress == KeyEnter || it.curState.chPress == ' ')
+}
+
+// label draws text word wrapped inside b, placed according to the padding and alignment of class
+func (it *Imterm) label(b Box, text string, class string) {
+	s := it.GetStyle(class)
+	b = b.inset(s.Padding)
+	lines := wrapCells(it.cells(text, s.Fg, s.Bg), b.w)
+	y, _ := align(s.VAlign, b.y, len(lines), b.h)
+	for i, line := range lines {
+		if i >= b.h {
+			break
+		}
+		x, _ := align(s.HAlign, b.x, len(line), b.w)
+		it.cellRow(x, y+i, b.x+b.w-x, line)
+	}
+}
+
+func (it *Imterm) rowText(x, y, w int, text string, s CalcedStyle) {
+	cx := 0
+	for _, r := range text {
+		if cx >= w {
+			break
+		}
+		it.setCell(x+cx, y, r, s.Fg, s.Bg)
+		cx++
+	}
+}
+
+// Place a single row checkbox
+func (it *Imterm) Checkbox(width Size, label string, checked bool) bool {
+	id := it.getID(label)
+	it.setLast(id)
+	it.tabStop()
+	b := it.getFitBox(width, 1, "checkbox", fixedSize(utf8.RuneCountInString(label)+4, 1))
+	it.PushClip(b.x, b.y, b.w, b.h)
+	defer it.PopClip()
+	x, y, w := b.x, b.y, b.w
+
+	if it.CheckClick(x, y, w, 1) == MouseLeft {
+		it.SetFocus(id)
+		checked = !checked
+	} else if it.Focus() && it.activated() {
+		checked = !checked
+	}
 
-	it.frame(b, "", class+".border")
+	mark := "[ ]"
+	if checked {
+		mark = "[x]"
+	}
+	it.selected = checked
+	it.rowText(x, y, w, mark, it.GetStyle("checkbox.box"))
+	it.rowText(x+4, y, w-4, label, it.GetStyle("checkbox.text"))
+
+	return checked
+}
+
+type headerState struct {
+	open bool
+}
+
+// Place a single row header filling the remaining width, which opens and closes when clicked or activated.
+// Returns whether it is open, so the items of its section can be placed only then.
+func (it *Imterm) CollapsingHeader(label string) bool {
+	id := it.getID(label)
+	it.setLast(id)
+	it.tabStop()
+	b := it.getFitBox(0, 1, "header", nil)
+	it.PushClip(b.x, b.y, b.w, b.h)
+	defer it.PopClip()
+	x, y, w := b.x, b.y, b.w
+
+	state := it.getState(id, &headerState{}).(*headerState)
+	if it.CheckClick(x, y, w, 1) == MouseLeft {
+		it.SetFocus(id)
+		state.open = !state.open
+	} else if it.Focus() && it.activated() {
+		state.open = !state.open
+	}
+
+	class := "header"
+	mark := "▶"
+	if state.open {
+		class += ".open"
+		mark = "▼"
+	}
+	it.selected = state.open
 	s := it.GetStyle(class + ".text")
-	wrapped := wordwrap.WrapString(label, uint(w-2))
-	cx, cy := x+1, y+1
+	for cx := 0; cx < w; cx++ {
+		it.setCell(x+cx, y, ' ', s.Fg, s.Bg)
+	}
+	it.rowText(x, y, w, mark, it.GetStyle(class+".arrow"))
+	it.rowText(x+2, y, w-2, label, s)
 
-	for _, r := range wrapped {
-		if r == '\n' {
-			cx, cy = x+1, cy+1
-			if cy >= y+h {
-				break
+	return state.open
+}
+
+// Place a group of radio buttons, one row per option.  Returns the index of the selected option.  An empty group
+// takes no space.
+func (it *Imterm) RadioGroup(width Size, label string, options []string, selected int) int {
+	id := it.getID(label)
+	it.setLast(id)
+	if len(options) == 0 {
+		// a height of 0 would take up the rest of the screen
+		return selected
+	}
+	it.tabStop()
+	b := it.getFitBox(width, Size(len(options)), "radio", fixedSize(longest(options)+4, len(options)))
+	it.PushClip(b.x, b.y, b.w, b.h)
+	defer it.PopClip()
+	x, y, w := b.x, b.y, b.w
+
+	for i := range options {
+		if it.CheckClick(x, y+i, w, 1) == MouseLeft {
+			it.SetFocus(id)
+			selected = i
+		}
+	}
+	if it.Focus() {
+		switch it.curState.keyPress {
+		case KeyArrowUp:
+			if selected > 0 {
+				selected--
+			}
+		case KeyArrowDown:
+			if selected < len(options)-1 {
+				selected++
 			}
-		} else {
-			it.screen.SetCell(cx, cy, r, s.Fg, s.Bg)
-			cx++
 		}
 	}
 
-	return state
+	for i, option := range options {
+		mark := "( )"
+		if i == selected {
+			mark = "(•)"
+		}
+		it.selected = i == selected
+		row := Box{x, y + i, w, 1}
+		it.rowText(x, y+i, w, mark, it.rowStyle("radio.box", row))
+		it.rowText(x+4, y+i, w-4, option, it.rowStyle("radio.text", row))
+	}
+
+	return selected
 }
 
 // Place a gauge, percent is a float from 0-1
-func (it *Imterm) Gauge(w, h int, label string, percent float32, overlay string) {
+func (it *Imterm) Gauge(width, height Size, label string, percent float32, overlay string) {
 	id := it.getID(label)
 	it.setLast(id)
-	b := it.getBox(w, h)
-	x, y, w, h := b.x, b.y, b.w, b.h
+	fw, fh := it.frameInset("gauge.border", label).size()
+	gw := utf8.RuneCountInString(overlay) + fw
+	lw := utf8.RuneCountInString(label)
+	if fw > 0 {
+		lw += 4
+	}
+	if lw > gw {
+		gw = lw
+	}
+	b := it.getFitBox(width, height, "gauge", fixedSize(gw, 1+fh))
+	it.PushClip(b.x, b.y, b.w, b.h)
+	defer it.PopClip()
 
 	overlaywidth := len(overlay)
 
-	it.frame(b, label, "gauge.border")
+	in := it.frame(b, label, "gauge.border")
+	x, y, w, h := in.x, in.y, in.w, in.h
 	s := it.GetStyle("gauge.bar.on")
 	wasactive := true
-	for cx := 0; cx < w-2; cx++ {
-		if wasactive && (float32(cx)/float32(w-1)) >= percent {
+	for cx := 0; cx < w; cx++ {
+		if wasactive && (float32(cx)/float32(w+1)) >= percent {
 			s = it.GetStyle("gauge.bar.off")
 		}
 		overlayPrinted := false
-		for cy := 0; cy < h-2; cy++ {
-			if !overlayPrinted && (cy+1) > ((h-2)/2) && cx >= ((w-2)/2-(overlaywidth/2)) && cx < ((w-2)/2-(overlaywidth/2))+overlaywidth {
-				it.screen.SetCell(cx+x+1, cy+y+1, rune(overlay[cx-((w-2)/2-(overlaywidth/2))]), s.Fg, s.Bg)
+		for cy := 0; cy < h; cy++ {
+			if !overlayPrinted && (cy+1) > (h/2) && cx >= (w/2-(overlaywidth/2)) && cx < (w/2-(overlaywidth/2))+overlaywidth {
+				it.setCell(cx+x, cy+y, rune(overlay[cx-(w/2-(overlaywidth/2))]), s.Fg, s.Bg)
 				overlayPrinted = true
 			} else {
-				it.screen.SetCell(cx+x+1, cy+y+1, ' ', s.Fg, s.Bg)
+				it.setCell(cx+x, cy+y, ' ', s.Fg, s.Bg)
 			}
 		}
 	}
@@ -859,46 +1516,58 @@ type listState struct {
 	scroll int
 }
 
+// listScroll draws the scroll arrows for a list framed by b with contents inside in, and returns the scroll
+// offset updated by any clicks on them or the mouse wheel
+func (it *Imterm) listScroll(b, in Box, scroll, items int, s CalcedStyle) int {
+	bd := it.GetStyle("list.border").Border
+	arrows := !bd.none()
+	if arrows {
+		it.setCell(b.x+b.w-1, b.y+1, bd.Up, s.Fg, s.Bg)
+	}
+	if scroll > 0 {
+		if arrows && it.CheckClick(b.x+b.w-1, b.y+1, 1, 1) == MouseLeft {
+			scroll--
+		}
+		if it.CheckClick(b.x, b.y, b.w, b.h) == MouseWheelUp {
+			scroll--
+		}
+	}
+	if arrows {
+		it.setCell(b.x+b.w-1, b.y+b.h-2, bd.Down, s.Fg, s.Bg)
+	}
+	if scroll < items-in.h {
+		if arrows && it.CheckClick(b.x+b.w-1, b.y+b.h-2, 1, 1) == MouseLeft {
+			scroll++
+		}
+		if it.CheckClick(b.x, b.y, b.w, b.h) == MouseWheelDown {
+			scroll++
+		}
+	}
+	return scroll
+}
+
 // Place a list area, user can scroll if there are too many items
-func (it *Imterm) List(w, h int, label string, contents []string) {
+func (it *Imterm) List(width, height Size, label string, contents []string) {
 	id := it.getID(label)
 	it.setLast(id)
-	b := it.getBox(w, h)
-	x, y, w, h := b.x, b.y, b.w, b.h
+	fw, fh := it.frameInset("list.border", label).size()
+	b := it.getFitBox(width, height, "list", fixedSize(it.longestMarkup(contents)+fw, len(contents)+fh))
+	it.PushClip(b.x, b.y, b.w, b.h)
+	defer it.PopClip()
 
-	it.frame(b, label, "list.border")
+	in := it.frame(b, label, "list.border")
+	x, y, w, h := in.x, in.y, in.w, in.h
 
 	s := it.GetStyle("list.items")
 
-	state := it.getState(label, &listState{}).(*listState)
+	state := it.getState(id, &listState{}).(*listState)
+	state.scroll = it.listScroll(b, in, state.scroll, len(contents), s)
 
-	cy := 0
-
-	it.screen.SetCell(x+w-1, y+1, '▲', s.Fg, s.Bg)
-	if state.scroll > 0 {
-		if it.CheckClick(x+w-1, y+1, 1, 1) == MouseLeft {
-			state.scroll--
-		}
-	}
-	it.screen.SetCell(x+w-1, y+h-2, '▼', s.Fg, s.Bg)
-	if state.scroll < len(contents)-(h-2) {
-		if it.CheckClick(x+w-1, y+h-2, 1, 1) == MouseLeft {
-			state.scroll++
-		}
-	}
-
-	for cy = 0; cy < h-2; cy++ {
-		cx := 0
+	for cy := 0; cy < h; cy++ {
 		if cy+state.scroll >= len(contents) {
 			break
 		}
-		for _, ch := range contents[cy+state.scroll] {
-			if cx > w-2 {
-				break
-			}
-			it.screen.SetCell(cx+x+1, cy+y+1, ch, s.Fg, s.Bg)
-			cx++
-		}
+		it.markupRow(x, cy+y, w, contents[cy+state.scroll], s)
 	}
 }
 
@@ -907,39 +1576,25 @@ type selectableListState struct {
 }
 
 // Place a selectable list.  User can scroll, and returns a slice of ints of which indexes are selected.
-func (it *Imterm) SelectableList(w, h int, label string, contents []string, selected []int) []int {
+func (it *Imterm) SelectableList(width, height Size, label string, contents []string, selected []int) []int {
 	id := it.getID(label)
 	it.setLast(id)
-	b := it.getBox(w, h)
-	x, y, w, h := b.x, b.y, b.w, b.h
+	fw, fh := it.frameInset("list.border", label).size()
+	b := it.getFitBox(width, height, "list", fixedSize(it.longestMarkup(contents)+fw, len(contents)+fh))
+	it.PushClip(b.x, b.y, b.w, b.h)
+	defer it.PopClip()
 
-	if it.CheckClick(x, y, w, h) == MouseLeft {
+	if it.CheckClick(b.x, b.y, b.w, b.h) == MouseLeft {
 		it.SetFocus(id)
 	}
 
-	it.frame(b, label, "list.border")
-
-	s := it.GetStyle("list.items")
-
-	state := it.getState(label, &selectableListState{}).(*selectableListState)
+	in := it.frame(b, label, "list.border")
+	x, y, w, h := in.x, in.y, in.w, in.h
 
-	cy := 0
+	state := it.getState(id, &selectableListState{}).(*selectableListState)
+	state.scroll = it.listScroll(b, in, state.scroll, len(contents), it.GetStyle("list.items"))
 
-	it.screen.SetCell(x+w-1, y+1, '▲', s.Fg, s.Bg)
-	if state.scroll > 0 {
-		if it.CheckClick(x+w-1, y+1, 1, 1) == MouseLeft {
-			state.scroll--
-		}
-	}
-	it.screen.SetCell(x+w-1, y+h-2, '▼', s.Fg, s.Bg)
-	if state.scroll < len(contents)-(h-2) {
-		if it.CheckClick(x+w-1, y+h-2, 1, 1) == MouseLeft {
-			state.scroll++
-		}
-	}
-
-	for cy = 0; cy < h-2; cy++ {
-		cx := 0
+	for cy := 0; cy < h; cy++ {
 		if cy+state.scroll >= len(contents) {
 			break
 		}
@@ -952,7 +1607,7 @@ func (it *Imterm) SelectableList(w, h int, label string, contents []string, sele
 				break
 			}
 		}
-		if it.CheckClick(x+1, y+cy+1, w-2, 1) == MouseLeft {
+		if it.CheckClick(x, y+cy, w, 1) == MouseLeft {
 			if iselected {
 				selected = append(selected[:selindex], selected[selindex+1:]...)
 			} else {
@@ -960,20 +1615,16 @@ func (it *Imterm) SelectableList(w, h int, label string, contents []string, sele
 			}
 			iselected = !iselected
 		}
-		for _, ch := range contents[cy+state.scroll] {
-			if cx >= w-2 {
-				break
-			}
-			if !iselected {
-				it.screen.SetCell(cx+x+1, cy+y+1, ch, s.Fg, s.Bg)
-			} else {
-				it.screen.SetCell(cx+x+1, cy+y+1, ch, s.Fg|AttrReverse, s.Bg|AttrReverse)
-			}
-			cx++
+		it.selected = iselected
+		s := it.rowStyle("list.items", Box{x, y + cy, w, 1})
+		if iselected && !it.hasStateStyle("list.items", "selected") {
+			s.Fg, s.Bg = s.Fg|AttrReverse, s.Bg|AttrReverse
 		}
+		cells := it.cells(contents[cy+state.scroll], s.Fg, s.Bg)
+		it.cellRow(x, cy+y, w, cells)
 		if iselected {
-			for ; cx < w-2; cx++ {
-				it.screen.SetCell(cx+x+1, cy+y+1, ' ', s.Fg|AttrReverse, s.Bg|AttrReverse)
+			for cx := len(cells); cx < w; cx++ {
+				it.setCell(cx+x, cy+y, ' ', s.Fg, s.Bg)
 			}
 		}
 	}
@@ -991,5 +1642,9 @@ func (it *Imterm) SameLine() {
 
 // Finishes and renders the frame
 func (it *Imterm) Finish() {
+	it.moveFocus()
+	it.finishLayers()
+	it.finishModal()
+	it.collectState()
 	it.screen.Flip()
 }
@@ -0,0 +1,129 @@
+package imterm
+
+import "testing"
+
+func TestTabFocus(t *testing.T) {
+	it, _ := New(nullScreen{})
+	pressed := 0
+	frame := func() {
+		it.Start()
+		if it.Button(10, 3, "a") {
+			pressed++
+		}
+		it.BeginDisabled(true)
+		it.Button(10, 3, "b")
+		it.EndDisabled()
+		it.Checkbox(10, "c", false)
+		it.Finish()
+	}
+	frame()
+
+	for i, step := range []struct {
+		mod  Modifier
+		want string
+	}{
+		{0, "a"},
+		{0, "c"},
+		{0, "a"},
+		{ModShift, "c"},
+		{ModShift, "a"},
+	} {
+		it.KeyboardMod(KeyTab, 0, step.mod)
+		frame()
+		if it.focusID != step.want {
+			t.Fatalf("step %d: focus on %q, want %q", i, it.focusID, step.want)
+		}
+	}
+
+	it.Keyboard(KeyEnter, 0)
+	frame()
+	if pressed != 1 {
+		t.Fatalf("Enter on a button focused by Tab pressed it %d times, want 1", pressed)
+	}
+}
+
+func TestClickedButtonIgnoresKeys(t *testing.T) {
+	it, _ := New(nullScreen{})
+	pressed := 0
+	frame := func() {
+		it.Start()
+		if it.Button(10, 3, "a") {
+			pressed++
+		}
+		it.Finish()
+	}
+	frame()
+	it.Mouse(1, 1, MouseLeft)
+	frame()
+	it.Mouse(1, 1, MouseRelease)
+	frame()
+	if pressed != 1 || it.focusID != "a" {
+		t.Fatalf("click pressed %d times with focus on %q", pressed, it.focusID)
+	}
+
+	it.Keyboard(KeySpace, 0)
+	frame()
+	if pressed != 1 {
+		t.Fatal("Space pressed a button that was focused by clicking it")
+	}
+}
+
+type fgScreen struct {
+	nullScreen
+	fg map[[2]int]Attribute
+}
+
+func (s *fgScreen) SetCell(x, y int, ch rune, fg, bg Attribute) {
+	s.fg[[2]int{x, y}] = fg
+}
+
+func TestRowHover(t *testing.T) {
+	s := &fgScreen{fg: map[[2]int]Attribute{}}
+	it, _ := New(s)
+	it.baseStyle["radio.text:hover"] = Style{FgStyle: AttrStrikethrough}
+	frame := func() {
+		it.Start()
+		it.RadioGroup(20, "r", []string{"a", "b", "c"}, 0)
+		it.Finish()
+	}
+	hovered := func() (rows []int) {
+		for y := 0; y < 3; y++ {
+			if s.fg[[2]int{4, y}]&AttrStrikethrough != 0 {
+				rows = append(rows, y)
+			}
+		}
+		return
+	}
+
+	frame()
+	if rows := hovered(); len(rows) != 0 {
+		t.Fatalf("rows %v hovered before the pointer was seen", rows)
+	}
+	it.Mouse(5, 1, MouseLeft)
+	frame()
+	if rows := hovered(); len(rows) != 1 || rows[0] != 1 {
+		t.Fatalf("rows %v hovered, want only the row under the pointer", rows)
+	}
+	it.Mouse(5, 1, MouseRelease)
+	it.ClearPointer()
+	frame()
+	if rows := hovered(); len(rows) != 0 {
+		t.Fatalf("rows %v still hovered once the pointer was cleared", rows)
+	}
+}
+
+func TestSetSelected(t *testing.T) {
+	it, _ := New(nullScreen{})
+	it.baseStyle["custom:selected"] = Style{FgStyle: AttrStrikethrough}
+	it.Start()
+	it.SetLast(it.GetID("a"))
+	it.SetSelected(true)
+	if it.GetStyle("custom").Fg&AttrStrikethrough == 0 {
+		t.Error("SetSelected didn't apply the :selected style")
+	}
+	it.SetLast(it.GetID("b"))
+	if it.GetStyle("custom").Fg&AttrStrikethrough != 0 {
+		t.Error("SetLast kept the item before it selected")
+	}
+	it.Finish()
+}
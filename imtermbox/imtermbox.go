@@ -6,11 +6,107 @@ import (
 	tb "github.com/nsf/termbox-go"
 )
 
+// TermAdapter draws to termbox.  Colors are shown according to termbox's output mode, so call
+// termbox.SetOutputMode with Output256 or OutputRGB to use more than the basic colors.  Curly and double
+// underlines are drawn as plain underlines, and strikethrough and overline aren't supported by termbox.
 type TermAdapter struct {
+	mode imterm.ColorMode
+}
+
+var attrs = []struct {
+	from imterm.Attribute
+	to   tb.Attribute
+}{
+	{imterm.AttrBold, tb.AttrBold},
+	{imterm.AttrUnderline, tb.AttrUnderline},
+	{imterm.AttrReverse, tb.AttrReverse},
+	{imterm.AttrItalic, tb.AttrCursive},
+	{imterm.AttrDim, tb.AttrDim},
+	{imterm.AttrBlink, tb.AttrBlink},
+	{imterm.AttrCurlyUnderline, tb.AttrUnderline},
+	{imterm.AttrDoubleUnderline, tb.AttrUnderline},
+}
+
+// ColorMode asks termbox for its output mode, which Imterm does once at the start of each frame, and remembers
+// it for converting the colors of that frame
+func (ta *TermAdapter) ColorMode() imterm.ColorMode {
+	switch tb.SetOutputMode(tb.OutputCurrent) {
+	case tb.Output256:
+		ta.mode = imterm.Colors256
+	case tb.OutputRGB:
+		ta.mode = imterm.ColorsRGB
+	default:
+		ta.mode = imterm.Colors8
+	}
+	return ta.mode
+}
+
+// attribute converts a, already downsampled to the output mode, to termbox
+func (ta *TermAdapter) attribute(a imterm.Attribute) tb.Attribute {
+	var ret tb.Attribute
+	for _, attr := range attrs {
+		if a&attr.from != 0 {
+			ret |= attr.to
+		}
+	}
+	c := a.Color()
+	if c == imterm.ColorDefault {
+		return ret
+	}
+	switch ta.mode {
+	case imterm.Colors256:
+		if c > imterm.ColorWhite {
+			// Output256 expects palette indexes offset by one, the basic colors already are
+			return ret | tb.Attribute(c&0xff+1)
+		}
+	case imterm.ColorsRGB:
+		r, g, b, _ := c.RGB()
+		return ret | tb.RGBToAttribute(r, g, b)
+	}
+	return ret | tb.Attribute(c)
+}
+
+var buttons = map[tb.Key]imterm.MouseButton{
+	tb.MouseLeft:      imterm.MouseLeft,
+	tb.MouseMiddle:    imterm.MouseMiddle,
+	tb.MouseRight:     imterm.MouseRight,
+	tb.MouseRelease:   imterm.MouseRelease,
+	tb.MouseWheelUp:   imterm.MouseWheelUp,
+	tb.MouseWheelDown: imterm.MouseWheelDown,
+}
+
+// Event passes a key or mouse event from termbox on to it.  Alt is taken from the event, and Ctrl from the
+// control key codes, other than those shared with Tab, Enter, Esc and Backspace.  termbox doesn't report Ctrl
+// or Shift held with any other key, so combinations such as Ctrl+PgUp don't reach imterm, and tabs are switched
+// by focusing the tab strip instead.  termbox only reports the pointer moving while a button is held, so the
+// pointer is cleared on release and nothing is hovered until the next click.
+func (ta *TermAdapter) Event(it *imterm.Imterm, ev tb.Event) {
+	switch ev.Type {
+	case tb.EventKey:
+		var mod imterm.Modifier
+		if ev.Mod&tb.ModAlt != 0 {
+			mod |= imterm.ModAlt
+		}
+		if ev.Ch == 0 && ev.Key < tb.KeySpace {
+			switch ev.Key {
+			case tb.KeyTab, tb.KeyEnter, tb.KeyEsc, tb.KeyBackspace:
+			default:
+				mod |= imterm.ModCtrl
+			}
+		}
+		it.KeyboardMod(imterm.Key(ev.Key), ev.Ch, mod)
+	case tb.EventMouse:
+		if b, ok := buttons[ev.Key]; ok {
+			it.Mouse(ev.MouseX, ev.MouseY, b)
+			if b == imterm.MouseRelease {
+				it.ClearPointer()
+			}
+		}
+	}
 }
 
 func (ta *TermAdapter) SetCell(x, y int, ch rune, fg, bg imterm.Attribute) {
-	tb.SetCell(x, y, ch, tb.Attribute(fg), tb.Attribute(bg))
+	tb.SetCell(x, y, ch, ta.attribute(fg), ta.attribute(bg))
 }
 func (ta *TermAdapter) Size() (w, h int) {
 	return tb.Size()
@@ -19,5 +115,5 @@ func (ta *TermAdapter) Flip() {
 	tb.Flush()
 }
 func (ta *TermAdapter) Clear(bg imterm.Attribute) {
-	tb.Clear(tb.ColorDefault, tb.Attribute(bg))
+	tb.Clear(tb.ColorDefault, ta.attribute(bg))
 }
@@ -0,0 +1,244 @@
+package imterm
+
+type Align int
+
+const (
+	AlignStart Align = iota
+	AlignCenter
+	AlignEnd
+	AlignStretch
+)
+
+// Flex describes how one item of a row or column is sized.  Items start at Size cells, then any space left over
+// (or missing) is shared between items in proportion to their Weight, keeping each between Min and Max.  A Max
+// of 0 means no limit.
+type Flex struct {
+	Size   int
+	Weight int
+	Min    int
+	Max    int
+}
+
+// Layout describes a row or column.  Height follows the usual rule, where <= 0 means the remaining height minus
+// n, and Fit wraps a row around its tallest item or a column around the Size of its items.  Padding is left
+// empty around the edge of the layout, and Gap between items.  Align places items with an explicit size across
+// the layout, so vertically within a row, and horizontally within a column.
+type Layout struct {
+	Height  Size
+	Padding int
+	Gap     int
+	Align   Align
+}
+
+type layoutFrame struct {
+	flow  flow
+	slots []Box
+	cur   int
+	row   bool
+	align Align
+	split *splitState
+
+	key     layoutKey
+	content int
+
+	// a Fit row placed for the first time is laid out in the remaining height, then placed again around its
+	// contents once they are known
+	refit  bool
+	before flow
+	layout Layout
+}
+
+// layoutKey identifies a row or column from one frame to the next, by the ID scope it was placed in and how many
+// rows and columns were placed before it this frame
+type layoutKey struct {
+	scope string
+	n     int
+}
+
+func align(a Align, pos, size, avail int) (int, int) {
+	if avail <= size {
+		return pos, size
+	}
+	switch a {
+	case AlignCenter:
+		pos += (avail - size) / 2
+	case AlignEnd:
+		pos += avail - size
+	case AlignStretch:
+		size = avail
+	}
+	return pos, size
+}
+
+// distribute splits avail cells between items
+func distribute(avail int, items []Flex) []int {
+	sizes := make([]int, len(items))
+	frozen := make([]bool, len(items))
+	for {
+		free, weight := avail, 0
+		for i, item := range items {
+			if frozen[i] {
+				free -= sizes[i]
+			} else {
+				free -= item.Size
+				weight += item.Weight
+			}
+		}
+		cum := 0
+		for i, item := range items {
+			if frozen[i] {
+				continue
+			}
+			sizes[i] = item.Size
+			if weight > 0 {
+				sizes[i] += free*(cum+item.Weight)/weight - free*cum/weight
+				cum += item.Weight
+			}
+		}
+		changed := false
+		for i, item := range items {
+			if frozen[i] {
+				continue
+			}
+			if sizes[i] < item.Min || sizes[i] < 0 {
+				sizes[i] = item.Min
+				frozen[i], changed = true, true
+			} else if item.Max > 0 && sizes[i] > item.Max {
+				sizes[i] = item.Max
+				frozen[i], changed = true, true
+			}
+		}
+		if !changed {
+			return sizes
+		}
+	}
+}
+
+func (it *Imterm) beginLayout(l Layout, row bool, items []Flex) {
+	key := layoutKey{it.scopedID(""), it.layoutNum}
+	it.layoutNum++
+	before := it.flow
+	var measure measureFunc
+	refit := false
+	if row {
+		if h, ok := it.fitLast[key]; ok {
+			measure = fixedSize(0, h+2*l.Padding)
+		} else {
+			refit = l.Height.unit() == unitFit
+		}
+	} else {
+		h := 0
+		for _, item := range items {
+			h += item.Size
+		}
+		if len(items) > 1 {
+			h += l.Gap * (len(items) - 1)
+		}
+		measure = fixedSize(0, h+2*l.Padding)
+	}
+	b := it.getFitBox(0, l.Height, "", measure)
+	inner := Box{b.x + l.Padding, b.y + l.Padding, b.w - 2*l.Padding, b.h - 2*l.Padding}
+
+	main := inner.h
+	if row {
+		main = inner.w
+	}
+	gaps := 0
+	if len(items) > 1 {
+		gaps = l.Gap * (len(items) - 1)
+	}
+	sizes := distribute(main-gaps, items)
+
+	lf := layoutFrame{flow: it.flow, row: row, align: l.Align, key: key, refit: refit, before: before, layout: l}
+	pos := 0
+	for _, size := range sizes {
+		if row {
+			lf.slots = append(lf.slots, Box{inner.x + pos, inner.y, size, inner.h})
+		} else {
+			lf.slots = append(lf.slots, Box{inner.x, inner.y + pos, inner.w, size})
+		}
+		pos += size + l.Gap
+	}
+	it.layoutStack = append(it.layoutStack, lf)
+	it.startSlot()
+}
+
+func (it *Imterm) startSlot() {
+	lf := &it.layoutStack[len(it.layoutStack)-1]
+	if lf.cur >= len(lf.slots) {
+		it.PushClip(0, 0, 0, 0)
+		return
+	}
+	s := lf.slots[lf.cur]
+	it.PushClip(s.x, s.y, s.w, s.h)
+	it.flow = flow{
+		xPos:        s.x,
+		yPos:        s.y,
+		columnX:     s.x,
+		columnY:     s.y,
+		columnWidth: s.w,
+		bottom:      s.y + s.h,
+	}
+	if lf.row {
+		it.vAlign = lf.align
+	} else {
+		it.hAlign = lf.align
+	}
+}
+
+// Start a row of items laid out left to right, sized according to items.  Call Next to move on to the next item.
+func (it *Imterm) BeginRow(l Layout, items ...Flex) {
+	it.beginLayout(l, true, items)
+}
+
+// Start a column of items laid out top to bottom, sized according to items.  Call Next to move on to the next item.
+func (it *Imterm) BeginColumn(l Layout, items ...Flex) {
+	it.beginLayout(l, false, items)
+}
+
+// finishSlot records how tall the contents of the current slot were
+func (it *Imterm) finishSlot() {
+	it.PopClip()
+	lf := &it.layoutStack[len(it.layoutStack)-1]
+	if lf.cur >= len(lf.slots) {
+		return
+	}
+	bottom := it.yPos
+	if it.columnMaxY > bottom {
+		bottom = it.columnMaxY
+	}
+	if h := bottom - lf.slots[lf.cur].y; h > lf.content {
+		lf.content = h
+	}
+}
+
+// Move on to the next item in the current row or column
+func (it *Imterm) Next() {
+	it.finishSlot()
+	it.layoutStack[len(it.layoutStack)-1].cur++
+	it.startSlot()
+}
+
+func (it *Imterm) endLayout() {
+	it.finishSlot()
+	lf := it.layoutStack[len(it.layoutStack)-1]
+	it.layoutStack = it.layoutStack[:len(it.layoutStack)-1]
+	it.flow = lf.flow
+	if lf.refit {
+		it.flow = lf.before
+		it.getFitBox(0, lf.layout.Height, "", fixedSize(0, lf.content+2*lf.layout.Padding))
+	}
+	if lf.row {
+		it.fitNext[lf.key] = lf.content
+	}
+}
+
+// Finish the current row
+func (it *Imterm) EndRow() {
+	it.endLayout()
+}
+
+// Finish the current column
+func (it *Imterm) EndColumn() {
+	it.endLayout()
+}
@@ -0,0 +1,27 @@
+package imterm
+
+import "testing"
+
+func TestNestedFitRows(t *testing.T) {
+	it, _ := New(nullScreen{})
+	var ys []int
+	for i := 0; i < 5; i++ {
+		it.Start()
+		it.BeginRow(Layout{Height: Fit}, Flex{Weight: 1})
+		it.BeginRow(Layout{Height: Fit}, Flex{Weight: 1}, Flex{Weight: 1})
+		it.Text(0, 3, "a", "a")
+		it.Next()
+		it.Text(0, 2, "b", "b")
+		it.EndRow()
+		it.EndRow()
+		it.Text(0, 1, "after", "after")
+		_, y, _, _ := it.GetLast()
+		ys = append(ys, y)
+		it.Finish()
+	}
+	for i, y := range ys {
+		if y != 3 {
+			t.Fatalf("frame %d: item after the rows at y %d, want 3 (%v)", i, y, ys)
+		}
+	}
+}
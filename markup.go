@@ -0,0 +1,217 @@
+package imterm
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// When Imterm.Markup is set, text placed by Text, List, SelectableList, Button, Toggle, the modal helpers and
+// frame labels can be styled with tags of the form [fg:bg:attrs], where fg and bg are basic color names or
+// #rrggbb, and attrs are letters from markupAttrs.  Any part can be left empty to keep it as it is, or set to - to go back to the
+// widget's own style, and a lone [-] resets everything, so "[red::b]error[-] message" shows error in bold red.
+// Anything in brackets that isn't a valid tag is shown as is, and [[ shows a single [.
+
+var markupAttrs = map[rune]Attribute{
+	'b': AttrBold,
+	'i': AttrItalic,
+	'd': AttrDim,
+	'u': AttrUnderline,
+	'U': AttrDoubleUnderline,
+	'c': AttrCurlyUnderline,
+	's': AttrStrikethrough,
+	'l': AttrBlink,
+	'o': AttrOverline,
+	'r': AttrReverse,
+}
+
+// Escape returns text with any [ doubled, so that it is shown as is rather than read as markup
+func Escape(text string) string {
+	return strings.ReplaceAll(text, "[", "[[")
+}
+
+func markupColor(s string) (Attribute, bool) {
+	if strings.HasPrefix(s, "#") {
+		c, err := parseColor(s)
+		return c, err == nil
+	}
+	c, ok := colorNames[strings.ToLower(s)]
+	return c, ok
+}
+
+// applyTag updates fg and bg according to the tag, which is the text between the brackets, and reports whether
+// it was a valid tag
+func applyTag(tag string, fg, bg *Attribute, baseFg, baseBg Attribute) bool {
+	if tag == "-" {
+		*fg, *bg = baseFg, baseBg
+		return true
+	}
+	fields := strings.Split(tag, ":")
+	if tag == "" || len(fields) > 3 {
+		return false
+	}
+	nfg, nbg := *fg, *bg
+	for i, field := range fields {
+		switch {
+		case field == "":
+			continue
+		case i < 2 && field == "-":
+			if i == 0 {
+				nfg = nfg.Attrs() | baseFg.Color()
+			} else {
+				nbg = nbg.Attrs() | baseBg.Color()
+			}
+		case i < 2:
+			c, ok := markupColor(field)
+			if !ok {
+				return false
+			}
+			if i == 0 {
+				nfg = nfg.Attrs() | c
+			} else {
+				nbg = nbg.Attrs() | c
+			}
+		case field == "-":
+			nfg = nfg.Color() | baseFg.Attrs()
+		default:
+			attrs := baseFg.Attrs()
+			for _, r := range field {
+				a, ok := markupAttrs[r]
+				if !ok {
+					return false
+				}
+				attrs |= a
+			}
+			nfg = nfg.Color() | attrs
+		}
+	}
+	*fg, *bg = nfg, nbg
+	return true
+}
+
+// parseMarkup turns text into cells, starting out in fg and bg
+func parseMarkup(text string, fg, bg Attribute) []Cell {
+	cells := make([]Cell, 0, len(text))
+	cfg, cbg := fg, bg
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if r == '[' {
+			if strings.HasPrefix(text[i:], "[[") {
+				cells = append(cells, Cell{'[', cfg, cbg})
+				i += 2
+				continue
+			}
+			if end := strings.IndexAny(text[i+1:], "[]\n"); end >= 0 && text[i+1+end] == ']' &&
+				applyTag(text[i+1:i+1+end], &cfg, &cbg, fg, bg) {
+				i += end + 2
+				continue
+			}
+		}
+		cells = append(cells, Cell{r, cfg, cbg})
+		i += size
+	}
+	return cells
+}
+
+// plainText returns text with its markup removed
+func plainText(text string) string {
+	if !strings.Contains(text, "[") {
+		return text
+	}
+	cells := parseMarkup(text, 0, 0)
+	runes := make([]rune, len(cells))
+	for i, c := range cells {
+		runes[i] = c.Char
+	}
+	return string(runes)
+}
+
+// plainCells turns text into cells as is, all in fg and bg
+func plainCells(text string, fg, bg Attribute) []Cell {
+	cells := make([]Cell, 0, len(text))
+	for _, r := range text {
+		cells = append(cells, Cell{r, fg, bg})
+	}
+	return cells
+}
+
+// cells turns text into cells starting out in fg and bg, reading its markup if Markup is set
+func (it *Imterm) cells(text string, fg, bg Attribute) []Cell {
+	if it.Markup {
+		return parseMarkup(text, fg, bg)
+	}
+	return plainCells(text, fg, bg)
+}
+
+// plain returns text as it will be shown, without its markup if Markup is set
+func (it *Imterm) plain(text string) string {
+	if it.Markup {
+		return plainText(text)
+	}
+	return text
+}
+
+// breakable reports whether a line can be broken at r.  No-break spaces are kept within words.
+func breakable(r rune) bool {
+	return r != '\u00a0' && r != '\u2007' && r != '\u202f' && unicode.IsSpace(r)
+}
+
+// wrapCells word wraps cells to w wide, counting every rune as one cell.  Lines are broken at line breaks and
+// between words, dropping the spaces at the break, and words too long for a line are left to be cut off.
+func wrapCells(cells []Cell, w int) [][]Cell {
+	if w <= 0 {
+		return nil
+	}
+	lines := [][]Cell{nil}
+	var space []Cell
+	start := 0
+	for i := 0; i <= len(cells); i++ {
+		if i < len(cells) && !breakable(cells[i].Char) {
+			continue
+		}
+		if word := cells[start:i]; len(word) > 0 {
+			line := lines[len(lines)-1]
+			if len(line) > 0 && len(line)+len(space)+len(word) > w {
+				lines = append(lines, nil)
+				line, space = nil, nil
+			}
+			lines[len(lines)-1] = append(append(line, space...), word...)
+			space = nil
+		}
+		start = i + 1
+		switch {
+		case i == len(cells):
+		case cells[i].Char == '\n':
+			lines = append(lines, nil)
+			space = nil
+		default:
+			space = append(space, cells[i])
+		}
+	}
+	return lines
+}
+
+// cellRow draws cells from x, y, cut off after w cells
+func (it *Imterm) cellRow(x, y, w int, cells []Cell) {
+	for i, c := range cells {
+		if i >= w {
+			break
+		}
+		it.setCell(x+i, y, c.Char, c.Fg, c.Bg)
+	}
+}
+
+// markupRow draws text, with its markup if Markup is set, from x, y, cut off after w cells
+func (it *Imterm) markupRow(x, y, w int, text string, s CalcedStyle) {
+	it.cellRow(x, y, w, it.cells(text, s.Fg, s.Bg))
+}
+
+// longestMarkup returns the width of the widest of items as they will be shown
+func (it *Imterm) longestMarkup(items []string) (w int) {
+	for _, item := range items {
+		if l := utf8.RuneCountInString(it.plain(item)); l > w {
+			w = l
+		}
+	}
+	return
+}
@@ -0,0 +1,81 @@
+package imterm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func cellText(cells []Cell) string {
+	r := make([]rune, len(cells))
+	for i, c := range cells {
+		r[i] = c.Char
+	}
+	return string(r)
+}
+
+func TestParseMarkup(t *testing.T) {
+	for _, c := range []struct {
+		in, text string
+		fg       []Attribute
+	}{
+		{"plain", "plain", nil},
+		{"[red]a[-]b", "ab", []Attribute{ColorRed, ColorWhite}},
+		{"[::b]a", "a", []Attribute{ColorWhite | AttrBold}},
+		{"[#ff0000]a", "a", []Attribute{RGB(255, 0, 0)}},
+		{"[[red]", "[red]", nil},
+		{"[red", "[red", nil},
+		{"a[red", "a[red", nil},
+		{"[red]]", "]", []Attribute{ColorRed}},
+		{"[nosuchcolor]a", "[nosuchcolor]a", nil},
+		{"[::z]a", "[::z]a", nil},
+		{"[a:b:c:d]", "[a:b:c:d]", nil},
+		{"[]", "[]", nil},
+		{"[red\n]a", "[red\n]a", nil},
+		{"[red[blue]a", "[reda", []Attribute{ColorWhite, ColorWhite, ColorWhite, ColorWhite, ColorBlue}},
+		{"日本[red]語", "日本語", []Attribute{ColorWhite, ColorWhite, ColorRed}},
+	} {
+		cells := parseMarkup(c.in, ColorWhite, ColorBlack)
+		if got := cellText(cells); got != c.text {
+			t.Errorf("%q: got text %q, want %q", c.in, got, c.text)
+			continue
+		}
+		if c.fg == nil {
+			continue
+		}
+		fg := make([]Attribute, len(cells))
+		for i, cell := range cells {
+			fg[i] = cell.Fg
+		}
+		if !reflect.DeepEqual(fg, c.fg) {
+			t.Errorf("%q: got colors %x, want %x", c.in, fg, c.fg)
+		}
+	}
+}
+
+func TestWrapCells(t *testing.T) {
+	for _, c := range []struct {
+		in   string
+		w    int
+		want []string
+	}{
+		{"hello world", 5, []string{"hello", "world"}},
+		{"hello world", 11, []string{"hello world"}},
+		{"a b c", 3, []string{"a b", "c"}},
+		{"  indent me", 8, []string{"  indent", "me"}},
+		{"one\ntwo three", 5, []string{"one", "two", "three"}},
+		{"toolongword x", 4, []string{"toolongword", "x"}},
+		{"a b c", 3, []string{"a b", "c"}},
+		{"日本語 日本", 3, []string{"日本語", "日本"}},
+		{"日本 語", 4, []string{"日本 語"}},
+		{"héllo wörld", 6, []string{"héllo", "wörld"}},
+		{"x", 0, nil},
+	} {
+		var got []string
+		for _, line := range wrapCells(plainCells(c.in, 0, 0), c.w) {
+			got = append(got, cellText(line))
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%q at %d: got %q, want %q", c.in, c.w, got, c.want)
+		}
+	}
+}
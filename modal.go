@@ -0,0 +1,232 @@
+package imterm
+
+import (
+	"math"
+)
+
+type ModalResult int
+
+const (
+	// The modal is closed, or still waiting on the user
+	ModalNone ModalResult = iota
+	ModalOK
+	ModalCancel
+)
+
+type modalState struct {
+	open   bool
+	value  string
+	opened int
+	fresh  bool
+}
+
+func (it *Imterm) startModal() {
+	it.modalOpening = ""
+	it.modalKeys = InputState{}
+	if it.modalCapture {
+		it.modalKeys.keyPress, it.modalKeys.chPress, it.modalKeys.modPress = it.curState.keyPress, it.curState.chPress, it.curState.modPress
+		it.curState.keyPress, it.curState.chPress, it.curState.modPress = 0, 0, 0
+	}
+}
+
+func (it *Imterm) finishModal() {
+	it.modalCapture = it.modalShown || it.modalOpening != ""
+	it.modalShown = false
+}
+
+// Open the modal with the given ID, it will be shown by the next call to BeginModal or one of the modal helpers with that ID.
+// Input is captured straight away, so items placed after this call, and those placed before the modal on the next
+// frame, don't take the input meant for the modal.
+func (it *Imterm) OpenModal(id string) {
+	id = it.scopedID(id)
+	state := it.getState(id, &modalState{}).(*modalState)
+	state.open = true
+	state.value = ""
+	state.opened = it.frameNum
+	state.fresh = true
+
+	// the rest of this frame's input most likely opened the modal
+	it.curState = InputState{}
+	it.modalOpening, it.modalCapture, it.hoverLayer, it.pointerLayer = id, true, id, id
+}
+
+// Close the modal currently being placed
+func (it *Imterm) CloseModal() {
+	state := it.getState(it.modalID, &modalState{}).(*modalState)
+	state.open = false
+}
+
+// Start a modal dialog if it is open.  The dialog is centered over the rest of the screen, which is overdrawn,
+// and captures all mouse and keyboard input until it is closed.  If this returns true, EndModal must be called
+// after placing the dialog's contents.
+func (it *Imterm) BeginModal(title string, w, h int) bool {
+	id := it.getID(title)
+	state := it.getState(id, &modalState{}).(*modalState)
+	if !state.open {
+		return false
+	}
+	it.setLast(id)
+	it.modalID = id
+	it.modalShown = true
+
+	it.windowStack = append(it.windowStack, windowFrame{it.flow, it.layer, it.clipStack})
+	l := &layer{id: id, z: math.MaxInt32, box: Box{0, 0, it.TermW, it.TermH}}
+	it.layers = append(it.layers, l)
+	it.layer = l
+	it.clipStack = []Box{l.box}
+
+	if it.modalCapture {
+		it.curState.keyPress, it.curState.chPress, it.curState.modPress = it.modalKeys.keyPress, it.modalKeys.chPress, it.modalKeys.modPress
+	}
+	if state.opened == it.frameNum || it.disabled {
+		// The keys of the frame the modal was opened in are likely what opened it, such as Enter on a focused
+		// widget, and shouldn't answer it straight away.  A disabled modal doesn't take keys at all.
+		it.curState.keyPress, it.curState.chPress, it.curState.modPress = 0, 0, 0
+	}
+
+	bs := it.GetStyle("modal.backdrop")
+	for cy := 0; cy < it.TermH; cy++ {
+		for cx := 0; cx < it.TermW; cx++ {
+			it.setCell(cx, cy, ' ', bs.Fg, bs.Bg)
+		}
+	}
+
+	b := Box{(it.TermW - w) / 2, (it.TermH - h) / 2, w, h}
+	it.lastBox = b
+	s := it.GetStyle("modal.background")
+	for cy := 0; cy < b.h; cy++ {
+		for cx := 0; cx < b.w; cx++ {
+			it.setCell(b.x+cx, b.y+cy, ' ', s.Fg, s.Bg)
+		}
+	}
+	in := it.frame(b, title, "modal.border")
+
+	it.flow = flow{
+		xPos:        in.x,
+		yPos:        in.y,
+		columnX:     in.x,
+		columnY:     in.y,
+		columnWidth: in.w,
+		bottom:      in.y + in.h,
+	}
+	return true
+}
+
+// Finish the current modal
+func (it *Imterm) EndModal() {
+	wf := it.windowStack[len(it.windowStack)-1]
+	it.windowStack = it.windowStack[:len(it.windowStack)-1]
+	it.flow, it.layer, it.clipStack = wf.flow, wf.layer, wf.clipStack
+	it.modalID = ""
+	if it.modalCapture {
+		it.curState.keyPress, it.curState.chPress, it.curState.modPress = 0, 0, 0
+	}
+}
+
+func (it *Imterm) modalSize(text string, extra int) (w, h int) {
+	w = it.TermW / 2
+	if w < 24 {
+		w = 24
+	}
+	if w > it.TermW {
+		w = it.TermW
+	}
+	lines := len(wrapCells(it.cells(text, 0, 0), w-2))
+	if lines < 1 {
+		lines = 1
+	}
+	return w, lines + extra + 2
+}
+
+func (it *Imterm) wrappedText(b Box, text string, s CalcedStyle) {
+	for i, line := range wrapCells(it.cells(text, s.Fg, s.Bg), b.w) {
+		if i >= b.h {
+			break
+		}
+		it.cellRow(b.x, b.y+i, b.w, line)
+	}
+}
+
+// Show a message with an OK button, if opened with OpenModal.  Returns ModalOK once dismissed.
+func (it *Imterm) MessageBox(title, text string) ModalResult {
+	w, h := it.modalSize(text, 3)
+	if !it.BeginModal(title, w, h) {
+		return ModalNone
+	}
+	it.PushID(title)
+	res := ModalNone
+	it.wrappedText(it.getBox(0, -3), text, it.GetStyle("modal.text"))
+	if it.Button(6, 3, "OK") ||
+		it.curState.keyPress == KeyEnter || it.curState.keyPress == KeyEsc {
+		res = ModalOK
+		it.CloseModal()
+	}
+	it.PopID()
+	it.EndModal()
+	return res
+}
+
+// Ask a yes or no question, if opened with OpenModal.  Returns ModalOK for yes and ModalCancel for no once answered.
+func (it *Imterm) Confirm(title, text string) ModalResult {
+	w, h := it.modalSize(text, 3)
+	if !it.BeginModal(title, w, h) {
+		return ModalNone
+	}
+	it.PushID(title)
+	res := ModalNone
+	it.wrappedText(it.getBox(0, -3), text, it.GetStyle("modal.text"))
+	if it.Button(7, 3, "Yes") ||
+		it.curState.keyPress == KeyEnter || it.curState.chPress == 'y' {
+		res = ModalOK
+	}
+	it.SameLine()
+	if it.Button(6, 3, "No") ||
+		it.curState.keyPress == KeyEsc || it.curState.chPress == 'n' {
+		res = ModalCancel
+	}
+	if res != ModalNone {
+		it.CloseModal()
+	}
+	it.PopID()
+	it.EndModal()
+	return res
+}
+
+// Ask the user to enter a line of text, if opened with OpenModal.  Returns the text and ModalOK once entered,
+// or ModalCancel if the user backs out.
+func (it *Imterm) Prompt(title, text string) (string, ModalResult) {
+	w, h := it.modalSize(text, 6)
+	if !it.BeginModal(title, w, h) {
+		return "", ModalNone
+	}
+	state := it.getState(it.modalID, &modalState{}).(*modalState)
+	res := ModalNone
+	switch it.curState.keyPress {
+	case KeyEnter:
+		res = ModalOK
+		it.curState.keyPress = 0
+	case KeyEsc:
+		res = ModalCancel
+	}
+	it.wrappedText(it.getBox(0, -6), text, it.GetStyle("modal.text"))
+	it.PushID(title)
+	inputID := it.scopedID("")
+	if state.fresh {
+		it.SetFocus(inputID)
+		state.fresh = false
+	}
+	state.value = it.Input(0, 3, "", state.value)
+	if it.Button(6, 3, "OK") {
+		res = ModalOK
+	}
+	it.SameLine()
+	if it.Button(10, 3, "Cancel") {
+		res = ModalCancel
+	}
+	if res != ModalNone {
+		it.CloseModal()
+	}
+	it.PopID()
+	it.EndModal()
+	return state.value, res
+}
@@ -0,0 +1,48 @@
+package imterm
+
+import "testing"
+
+func TestOpenModalCapturesInput(t *testing.T) {
+	it, _ := New(nullScreen{})
+	open, under := false, 0
+	frame := func() {
+		it.Start()
+		// the modal is placed before the button that opens it, so it is first shown on the frame after
+		it.Confirm("q", "?")
+		if it.Button(10, 3, "under") {
+			under++
+		}
+		if open {
+			it.OpenModal("q")
+			open = false
+		}
+		it.Finish()
+	}
+	frame()
+	open = true
+	frame()
+	it.Mouse(1, 1, MouseLeft)
+	frame()
+	if under != 0 {
+		t.Fatal("the item under a newly opened modal took its click")
+	}
+}
+
+func TestPromptFocus(t *testing.T) {
+	it, _ := New(nullScreen{})
+	it.OpenModal("p")
+	frame := func() {
+		it.Start()
+		it.Prompt("p", "name")
+		it.Finish()
+	}
+	frame()
+	input := it.focusID
+	it.Keyboard(KeyTab, 0)
+	frame()
+	ok := it.focusID
+	frame()
+	if ok == input || it.focusID != ok {
+		t.Fatalf("focus went from %q to %q then %q, want it to leave the input and stay", input, ok, it.focusID)
+	}
+}
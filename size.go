@@ -0,0 +1,176 @@
+package imterm
+
+import (
+	"math"
+	"unicode/utf8"
+)
+
+// Size is a length along one axis.  A plain number is a count of cells, while Pct and Fr build sizes relative to
+// the space available.
+type Size int
+
+const (
+	sizeUnitShift = 24
+	sizeValueMask = 1<<sizeUnitShift - 1
+)
+
+const (
+	unitCells = iota
+	unitPct
+	unitFr
+	unitFit
+)
+
+// Fit sizes an item to its contents
+const Fit Size = unitFit << sizeUnitShift
+
+// A percentage of the parent's size
+func Pct(n int) Size {
+	return Size(unitPct<<sizeUnitShift | n&sizeValueMask)
+}
+
+// A share of the space left over.  In a grid this is what is left once cells and percentages are taken out.  For
+// an item it is the remaining width or height, shared between all the Fr items placed along the same line or
+// column during the last frame.  Until there is a last frame to go by, items are sized as Fit.
+func Fr(n int) Size {
+	return Size(unitFr<<sizeUnitShift | n&sizeValueMask)
+}
+
+func (s Size) unit() int {
+	if s <= 0 {
+		return unitCells
+	}
+	return int(s) >> sizeUnitShift
+}
+
+func (s Size) value() int {
+	if s <= 0 {
+		return int(s)
+	}
+	return int(s) & sizeValueMask
+}
+
+// resolveSizes splits total cells between sizes
+func resolveSizes(total int, sizes []Size) []int {
+	ret := make([]int, len(sizes))
+	free, fr := total, 0
+	for i, s := range sizes {
+		switch s.unit() {
+		case unitCells:
+			ret[i] = s.value()
+			if ret[i] < 0 {
+				ret[i] = 0
+			}
+		case unitPct:
+			ret[i] = total * s.value() / 100
+		case unitFr:
+			fr += s.value()
+			continue
+		case unitFit:
+			// there is nothing to measure a track by, so it gets a share of what is left
+			fr++
+			continue
+		}
+		free -= ret[i]
+	}
+	if free < 0 {
+		free = 0
+	}
+	cum := 0
+	for i, s := range sizes {
+		n := s.value()
+		switch s.unit() {
+		case unitFit:
+			n = 1
+		case unitFr:
+		default:
+			continue
+		}
+		if fr > 0 {
+			ret[i] = free*(cum+n)/fr - free*cum/fr
+			cum += n
+		}
+	}
+	return ret
+}
+
+type frKey struct {
+	x, y, w  int
+	line     int
+	vertical bool
+}
+
+// measured reports whether s is sized by its contents, which Fr sizes are too when there is no total from the
+// last frame to share the space by
+func (s Size) measured(frTotal int) bool {
+	return s.unit() == unitFit || s.unit() == unitFr && frTotal == 0
+}
+
+// frShare returns n shares of remaining, given the shares used so far and the total shares from the last frame
+func frShare(n, remaining, used, total int) int {
+	left := total - used
+	if left <= n {
+		return remaining
+	}
+	return remaining * n / left
+}
+
+// resolveSize turns s into a number of cells, given the space remaining, the size of the parent, the natural size
+// and the Fr shares used so far
+func resolveSize(s Size, remaining, parent, fit, frUsed, frTotal int) int {
+	switch s.unit() {
+	case unitPct:
+		return parent * s.value() / 100
+	case unitFr:
+		if frTotal != 0 {
+			return frShare(s.value(), remaining, frUsed, frTotal)
+		}
+		// Nothing to share by yet, such as on the first frame, so size it as Fit until the next frame
+		fallthrough
+	case unitFit:
+		if fit > 0 {
+			return fit
+		}
+		return remaining
+	}
+	if s <= 0 {
+		return remaining + int(s)
+	}
+	return int(s)
+}
+
+// measureFunc reports the natural size of an item's contents when it is limited to maxW cells wide
+type measureFunc func(maxW int) (w, h int)
+
+func fixedSize(w, h int) measureFunc {
+	return func(int) (int, int) {
+		return w, h
+	}
+}
+
+// measureWrapped measures text word wrapped to fit inside a border of pad
+func measureWrapped(text string, pad Spacing) measureFunc {
+	return func(maxW int) (w, h int) {
+		padW, padH := pad.size()
+		width := maxW - padW
+		if width <= 0 {
+			width = math.MaxInt32
+		}
+		for _, line := range wrapCells(plainCells(text, 0, 0), width) {
+			if len(line) > w {
+				w = len(line)
+			}
+			h++
+		}
+		return w + padW, h + padH
+	}
+}
+
+func longest(items []string) (w int) {
+	for _, item := range items {
+		if l := utf8.RuneCountInString(item); l > w {
+			w = l
+		}
+	}
+	return
+}
@@ -0,0 +1,126 @@
+package imterm
+
+type splitState struct {
+	ratios []float64
+	panes  int
+	active int
+	drag   bool
+}
+
+// Start a set of panes filling the remaining space, separated by dividers that can be dragged with the mouse, or
+// moved with the arrow keys once clicked.  Panes are laid out left to right if horizontal is set, and top to bottom
+// otherwise.  Call SplitNext to move on to the next pane.
+func (it *Imterm) BeginSplit(horizontal bool, id string) {
+	id = it.getID(id)
+	it.setLast(id)
+	it.tabStop()
+	b := it.getBox(0, 0)
+	state := it.getState(id, &splitState{panes: 2}).(*splitState)
+
+	origin, main := b.y, b.h
+	pointer := it.pointerY
+	back, fwd := KeyArrowUp, KeyArrowDown
+	if horizontal {
+		origin, main = b.x, b.w
+		pointer = it.pointerX
+		back, fwd = KeyArrowLeft, KeyArrowRight
+	}
+
+	if len(state.ratios) != state.panes-1 {
+		state.ratios = state.ratios[:0]
+		for i := 1; i < state.panes; i++ {
+			state.ratios = append(state.ratios, float64(i)/float64(state.panes))
+		}
+		state.active = 0
+	}
+	pos := func(i int) int {
+		return int(state.ratios[i]*float64(main) + 0.5)
+	}
+	move := func(i, p int) {
+		lo, hi := 0, main-1
+		if i > 0 {
+			lo = pos(i-1) + 1
+		}
+		if i < len(state.ratios)-1 {
+			hi = pos(i+1) - 1
+		}
+		if p < lo {
+			p = lo
+		}
+		if p > hi {
+			p = hi
+		}
+		if main > 0 {
+			state.ratios[i] = float64(p) / float64(main)
+		}
+	}
+
+	if it.mouseState != MouseLeft || it.disabled {
+		state.drag = false
+	}
+	if state.drag {
+		move(state.active, pointer-origin)
+	}
+	for i := range state.ratios {
+		p := pos(i)
+		click := it.CheckClick(b.x, b.y+p, b.w, 1)
+		if horizontal {
+			click = it.CheckClick(b.x+p, b.y, 1, b.h)
+		}
+		if click == MouseLeft {
+			it.SetFocus(id)
+			state.active, state.drag = i, true
+		}
+	}
+	if it.Focus() && state.active < len(state.ratios) {
+		switch it.curState.keyPress {
+		case back:
+			move(state.active, pos(state.active)-1)
+		case fwd:
+			move(state.active, pos(state.active)+1)
+		}
+	}
+
+	lf := layoutFrame{flow: it.flow, split: state}
+	s := it.GetStyle("split.divider")
+	hch, vch := s.Border.Horizontal, s.Border.Vertical
+	if s.Border.none() {
+		hch, vch = ' ', ' '
+	}
+	start := 0
+	for i := 0; i <= len(state.ratios); i++ {
+		end := main
+		if i < len(state.ratios) {
+			end = pos(i)
+		}
+		if horizontal {
+			lf.slots = append(lf.slots, Box{b.x + start, b.y, end - start, b.h})
+			if end < main {
+				it.vLine(b.x+end, b.y, b.h-1, vch, s)
+			}
+		} else {
+			lf.slots = append(lf.slots, Box{b.x, b.y + start, b.w, end - start})
+			if end < main {
+				it.hLine(b.x, b.y+end, b.w-1, hch, s)
+			}
+		}
+		start = end + 1
+	}
+	it.layoutStack = append(it.layoutStack, lf)
+	it.startSlot()
+}
+
+// Move on to the next pane of the current split
+func (it *Imterm) SplitNext() {
+	it.Next()
+}
+
+// Finish the current split
+func (it *Imterm) EndSplit() {
+	lf := it.layoutStack[len(it.layoutStack)-1]
+	it.endLayout()
+	if lf.cur+1 != lf.split.panes {
+		lf.split.panes = lf.cur + 1
+		lf.split.ratios = nil
+	}
+}
@@ -0,0 +1,193 @@
+package imterm
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+)
+
+// DefaultStateFrames is the StateFrames a new Imterm starts with, about ten seconds at 60 frames a second
+const DefaultStateFrames = 600
+
+// Keep the state of the item with the given ID, scoped by PushID, even while it isn't being placed, such as the
+// scroll position of a list on a page that is only shown now and then
+func (it *Imterm) KeepState(id string) {
+	it.stateKeep[it.scopedID(id)] = true
+}
+
+// Let the state of the item with the given ID be dropped again once it stops being placed
+func (it *Imterm) ReleaseState(id string) {
+	delete(it.stateKeep, it.scopedID(id))
+}
+
+// seen notes that the item with the given ID was placed this frame, for collectState
+func (it *Imterm) seen(id string) {
+	if it.StateFrames > 0 {
+		it.stateSeen[id] = it.frameNum
+	}
+}
+
+// collectState drops the state of items that haven't been placed for StateFrames frames
+func (it *Imterm) collectState() {
+	if it.StateFrames <= 0 {
+		for id := range it.stateSeen {
+			delete(it.stateSeen, id)
+		}
+		return
+	}
+	for id, seen := range it.stateSeen {
+		if it.frameNum-seen < it.StateFrames || it.stateKeep[id] {
+			continue
+		}
+		delete(it.widgetState, id)
+		delete(it.stateSeen, id)
+		if it.focusID == id {
+			it.focusID = ""
+		}
+	}
+}
+
+// savedState is how built in widget state is written by SaveState
+type savedState struct {
+	Scroll  int       `json:"scroll,omitempty"`
+	XScroll int       `json:"xscroll,omitempty"`
+	Ratios  []float64 `json:"ratios,omitempty"`
+	Active  int       `json:"active,omitempty"`
+	Open    bool      `json:"open,omitempty"`
+	X       int       `json:"x,omitempty"`
+	Y       int       `json:"y,omitempty"`
+	W       int       `json:"w,omitempty"`
+	H       int       `json:"h,omitempty"`
+	Z       int       `json:"z,omitempty"`
+}
+
+type savedUI struct {
+	Focus   string                     `json:"focus,omitempty"`
+	Widgets map[string]json.RawMessage `json:"widgets"`
+}
+
+// SkippedStateError is returned by SaveState when the custom state of some items couldn't be written as JSON,
+// such as state holding a func or channel.  Everything else is still written.
+type SkippedStateError struct {
+	IDs []string
+}
+
+func (e *SkippedStateError) Error() string {
+	return "imterm: couldn't save the state of " + strings.Join(e.IDs, ", ")
+}
+
+// Write the focus and the state of each item, such as scroll positions, split ratios, open headers, active tabs
+// and window positions, to w as JSON.  Items whose state can't be written are left out, and reported with a
+// *SkippedStateError.
+func (it *Imterm) SaveState(w io.Writer) error {
+	ui := savedUI{Focus: it.focusID, Widgets: map[string]json.RawMessage{}}
+	var skipped []string
+	for id, raw := range it.stateLoaded {
+		ui.Widgets[id] = raw
+	}
+	for id, state := range it.widgetState {
+		var v interface{} = state
+		switch state := state.(type) {
+		case nil, *inputState, *modalState:
+			continue
+		case *textState:
+			v = savedState{Scroll: state.scroll}
+		case *listState:
+			v = savedState{Scroll: state.scroll}
+		case *selectableListState:
+			v = savedState{Scroll: state.scroll}
+		case *bufferState:
+			v = savedState{Scroll: state.yscroll, XScroll: state.xscroll}
+		case *childState:
+			v = savedState{Scroll: state.yscroll, XScroll: state.xscroll}
+		case *splitState:
+			v = savedState{Ratios: state.ratios}
+		case *tabState:
+			v = savedState{Active: state.active}
+		case *headerState:
+			v = savedState{Open: state.open}
+		case *windowState:
+			v = savedState{X: state.x, Y: state.y, W: state.w, H: state.h, Z: state.z}
+		}
+		raw, err := json.Marshal(v)
+		if err != nil {
+			skipped = append(skipped, id)
+			continue
+		}
+		ui.Widgets[id] = raw
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(ui); err != nil {
+		return err
+	}
+	if len(skipped) > 0 {
+		sort.Strings(skipped)
+		return &SkippedStateError{IDs: skipped}
+	}
+	return nil
+}
+
+// Read state written by SaveState from r.  The focus is restored straight away, and each item picks up its state
+// the next time it is placed, unless it already has state of its own.
+func (it *Imterm) LoadState(r io.Reader) error {
+	var ui savedUI
+	if err := json.NewDecoder(r).Decode(&ui); err != nil {
+		return err
+	}
+	for id, raw := range ui.Widgets {
+		it.stateLoaded[id] = raw
+	}
+	if ui.Focus != "" {
+		it.SetFocus(ui.Focus)
+	}
+	return nil
+}
+
+// restoreState fills in state from raw, written by SaveState, ignoring anything that doesn't fit
+func (it *Imterm) restoreState(raw json.RawMessage, state interface{}) {
+	var s savedState
+	switch state.(type) {
+	case nil, *inputState, *modalState:
+		return
+	case *textState, *listState, *selectableListState, *bufferState, *childState, *splitState, *tabState,
+		*headerState, *windowState:
+		if json.Unmarshal(raw, &s) != nil {
+			return
+		}
+	default:
+		json.Unmarshal(raw, state)
+		return
+	}
+	switch state := state.(type) {
+	case *textState:
+		state.scroll = s.Scroll
+	case *listState:
+		state.scroll = s.Scroll
+	case *selectableListState:
+		state.scroll = s.Scroll
+	case *bufferState:
+		state.yscroll, state.xscroll = s.Scroll, s.XScroll
+	case *childState:
+		state.yscroll, state.xscroll = s.Scroll, s.XScroll
+	case *splitState:
+		if len(s.Ratios) > 0 {
+			state.ratios, state.panes = s.Ratios, len(s.Ratios)+1
+		}
+	case *tabState:
+		state.active = s.Active
+	case *headerState:
+		state.open = s.Open
+	case *windowState:
+		if s.W > 0 && s.H > 0 {
+			state.x, state.y, state.w, state.h = s.X, s.Y, s.W, s.H
+		}
+		if s.Z > 0 {
+			state.z = s.Z
+			if it.topZ < s.Z {
+				it.topZ = s.Z
+			}
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package imterm
+
+type tabState struct {
+	active      int
+	focusInside bool
+}
+
+type tabFrame struct {
+	flow      flow
+	id        string
+	x, y, w   int
+	tabs      []string
+	state     *tabState
+	focusSeen int
+}
+
+// Start a tabbed container filling the remaining width.  Returns the index of the active tab, whose contents
+// should be placed before the matching EndTabs.  The active tab is changed by clicking it, with PgUp and PgDn or
+// the left and right arrows while the tab strip has the focus, or with Ctrl+PgUp and Ctrl+PgDn while an item
+// inside the container does.
+func (it *Imterm) BeginTabs(label string, tabs []string) int {
+	id := it.getID(label)
+	it.setLast(id)
+	it.tabStop()
+	state := it.getState(id, &tabState{}).(*tabState)
+	x, y := it.xPos, it.yPos
+	w := (it.columnX + it.columnWidth) - it.xPos
+
+	bs := it.frameInset("tabs.border", "").Top
+	tx := x + bs
+	for i, tab := range tabs {
+		tw := len(tab) + 2
+		if it.CheckClick(tx, y, tw, 1) == MouseLeft {
+			it.SetFocus(id)
+			state.active = i
+		}
+		tx += tw
+	}
+	if it.Focus() {
+		switch it.curState.keyPress {
+		case KeyPgup, KeyArrowLeft:
+			state.active--
+		case KeyPgdn, KeyArrowRight:
+			state.active++
+		}
+	} else if !it.disabled && state.focusInside && it.curState.modPress&ModCtrl != 0 {
+		switch it.curState.keyPress {
+		case KeyPgup:
+			state.active--
+		case KeyPgdn:
+			state.active++
+		}
+	}
+	if state.active >= len(tabs) {
+		state.active = len(tabs) - 1
+	}
+	if state.active < 0 {
+		state.active = 0
+	}
+
+	it.tabStack = append(it.tabStack, tabFrame{it.flow, id, x, y, w, tabs, state, it.focusSeen})
+	it.flow = flow{
+		xPos:        x + bs,
+		yPos:        y + 1,
+		columnX:     x + bs,
+		columnY:     y + 1,
+		columnWidth: w - 2*bs,
+		bottom:      it.bottom - bs,
+	}
+	return state.active
+}
+
+// Finish the current tabbed container, sizing it to fit the contents of the active tab
+func (it *Imterm) EndTabs() {
+	tf := it.tabStack[len(it.tabStack)-1]
+	it.tabStack = it.tabStack[:len(it.tabStack)-1]
+	tf.state.focusInside = it.focusSeen != tf.focusSeen
+
+	bottom := it.yPos
+	if it.columnMaxY > bottom {
+		bottom = it.columnMaxY
+	}
+	bs := it.frameInset("tabs.border", "").Top
+	h := bottom - tf.y + bs
+	if h < 1+2*bs {
+		h = 1 + 2*bs
+	}
+
+	it.flow = tf.flow
+	it.setLast(tf.id)
+	b := it.getBox(Size(tf.w), Size(h))
+	it.PushClip(b.x, b.y, b.w, b.h)
+	defer it.PopClip()
+	it.frame(b, "", "tabs.border")
+
+	s := it.GetStyle("tabs.border")
+	tx := b.x + bs
+	for i, tab := range tf.tabs {
+		class := "tabs.tab"
+		open, close := ' ', ' '
+		it.selected = i == tf.state.active
+		if it.selected {
+			class += ".active"
+			open, close = s.Border.LabelLeft, s.Border.LabelRight
+			if s.Border.none() {
+				open, close = '[', ']'
+			}
+		}
+		if tx+len(tab)+2 > b.x+b.w-bs {
+			break
+		}
+		it.setCell(tx, b.y, open, s.Fg, s.Bg)
+		it.rowText(tx+1, b.y, len(tab), tab, it.GetStyle(class))
+		it.setCell(tx+len(tab)+1, b.y, close, s.Fg, s.Bg)
+		tx += len(tab) + 2
+	}
+}
@@ -0,0 +1,271 @@
+package imterm
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var colorNames = map[string]Attribute{
+	"default": ColorDefault,
+	"black":   ColorBlack,
+	"red":     ColorRed,
+	"green":   ColorGreen,
+	"yellow":  ColorYellow,
+	"blue":    ColorBlue,
+	"magenta": ColorMagenta,
+	"cyan":    ColorCyan,
+	"white":   ColorWhite,
+}
+
+var attrNames = map[string]Attribute{
+	"bold":            AttrBold,
+	"underline":       AttrUnderline,
+	"reverse":         AttrReverse,
+	"italic":          AttrItalic,
+	"dim":             AttrDim,
+	"strikethrough":   AttrStrikethrough,
+	"blink":           AttrBlink,
+	"curlyUnderline":  AttrCurlyUnderline,
+	"doubleUnderline": AttrDoubleUnderline,
+	"overline":        AttrOverline,
+}
+
+var alignNames = map[string]Align{
+	"start":   AlignStart,
+	"center":  AlignCenter,
+	"end":     AlignEnd,
+	"stretch": AlignStretch,
+}
+
+var borderNames = map[string]Border{
+	"single":  BorderSingle,
+	"double":  BorderDouble,
+	"rounded": BorderRounded,
+	"heavy":   BorderHeavy,
+	"ascii":   BorderASCII,
+	"none":    BorderNone,
+}
+
+// themeStyle is how a Style is written in a theme file.  Colors are one of the basic color names, a number from the
+// 256 color palette, or #rrggbb.  Spacing is 1, 2 or 4 numbers, as in CSS, and a border
+// is either the name of one of the presets or a string of its glyphs in the order of the Border fields.
+type themeStyle struct {
+	Fg      string   `json:"fg,omitempty"`
+	Bg      string   `json:"bg,omitempty"`
+	FgAttrs []string `json:"fgAttrs,omitempty"`
+	BgAttrs []string `json:"bgAttrs,omitempty"`
+	Padding []int    `json:"padding,omitempty"`
+	Margin  []int    `json:"margin,omitempty"`
+	HAlign  string   `json:"halign,omitempty"`
+	VAlign  string   `json:"valign,omitempty"`
+	Border  string   `json:"border,omitempty"`
+}
+
+func nameOf[T comparable](names map[string]T, v T) string {
+	for name, nv := range names {
+		if nv == v {
+			return name
+		}
+	}
+	return ""
+}
+
+// lookup finds name in names ignoring case, so the names written by SaveTheme and hand written ones are both read
+func lookup[T any](names map[string]T, kind, name string) (T, error) {
+	if v, ok := names[name]; ok {
+		return v, nil
+	}
+	for n, v := range names {
+		if strings.EqualFold(n, name) {
+			return v, nil
+		}
+	}
+	var v T
+	return v, fmt.Errorf("imterm: unknown %s %q", kind, name)
+}
+
+func attrList(a Attribute) (names []string) {
+	for name, attr := range attrNames {
+		if a&attr != 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return
+}
+
+func parseAttrs(names []string) (a Attribute, err error) {
+	for _, name := range names {
+		attr, err := lookup(attrNames, "attribute", name)
+		if err != nil {
+			return 0, err
+		}
+		a |= attr
+	}
+	return a, nil
+}
+
+func spacingList(s Spacing) []int {
+	switch {
+	case s == Spacing{}:
+		return nil
+	case s == Even(s.Top):
+		return []int{s.Top}
+	case s.Top == s.Bottom && s.Left == s.Right:
+		return []int{s.Top, s.Right}
+	}
+	return []int{s.Top, s.Right, s.Bottom, s.Left}
+}
+
+func parseSpacing(n []int) (Spacing, error) {
+	switch len(n) {
+	case 0:
+		return Spacing{}, nil
+	case 1:
+		return Even(n[0]), nil
+	case 2:
+		return Spacing{n[0], n[1], n[0], n[1]}, nil
+	case 4:
+		return Spacing{n[0], n[1], n[2], n[3]}, nil
+	}
+	return Spacing{}, fmt.Errorf("imterm: spacing needs 1, 2 or 4 numbers, not %d", len(n))
+}
+
+// colorString names a basic color, and writes a 256 color index as a number and a true color as #rrggbb
+func colorString(c Attribute) string {
+	switch {
+	case c&colorRGB != 0:
+		r, g, b, _ := c.RGB()
+		return fmt.Sprintf("#%02x%02x%02x", r, g, b)
+	case c&colorIndexed != 0:
+		return strconv.Itoa(int(c & 0xff))
+	}
+	return nameOf(colorNames, c)
+}
+
+func parseColor(s string) (Attribute, error) {
+	if strings.HasPrefix(s, "#") && len(s) == 7 {
+		if v, err := strconv.ParseUint(s[1:], 16, 32); err == nil {
+			return RGB(uint8(v>>16), uint8(v>>8), uint8(v)), nil
+		}
+	}
+	if n, err := strconv.ParseUint(s, 10, 8); err == nil {
+		return Color256(uint8(n)), nil
+	}
+	return lookup(colorNames, "color", s)
+}
+
+func borderString(bd Border) string {
+	if name := nameOf(borderNames, bd); name != "" {
+		return name
+	}
+	return string([]rune{bd.Horizontal, bd.Vertical, bd.TopLeft, bd.TopRight, bd.BottomLeft, bd.BottomRight,
+		bd.LabelLeft, bd.LabelRight, bd.Up, bd.Down, bd.Left, bd.Right, bd.Track, bd.Thumb})
+}
+
+func parseBorder(s string) (Border, error) {
+	if bd, ok := borderNames[strings.ToLower(s)]; ok {
+		return bd, nil
+	}
+	r := []rune(s)
+	if len(r) != 14 {
+		return Border{}, fmt.Errorf("imterm: unknown border %q", s)
+	}
+	return Border{r[0], r[1], r[2], r[3], r[4], r[5], r[6], r[7], r[8], r[9], r[10], r[11], r[12], r[13], false}, nil
+}
+
+func toTheme(s Style) (ts themeStyle) {
+	if s.FgColor != 0 {
+		ts.Fg = colorString(s.FgColor)
+	}
+	if s.BgColor != 0 {
+		ts.Bg = colorString(s.BgColor)
+	}
+	ts.FgAttrs, ts.BgAttrs = attrList(s.FgStyle), attrList(s.BgStyle)
+	ts.Padding, ts.Margin = spacingList(s.Padding), spacingList(s.Margin)
+	if s.HAlign != 0 {
+		ts.HAlign = nameOf(alignNames, s.HAlign)
+	}
+	if s.VAlign != 0 {
+		ts.VAlign = nameOf(alignNames, s.VAlign)
+	}
+	if s.Border != (Border{}) {
+		ts.Border = borderString(s.Border)
+	}
+	return
+}
+
+func (ts themeStyle) style() (s Style, err error) {
+	if ts.Fg != "" {
+		if s.FgColor, err = parseColor(ts.Fg); err != nil {
+			return
+		}
+	}
+	if ts.Bg != "" {
+		if s.BgColor, err = parseColor(ts.Bg); err != nil {
+			return
+		}
+	}
+	if s.FgStyle, err = parseAttrs(ts.FgAttrs); err != nil {
+		return
+	}
+	if s.BgStyle, err = parseAttrs(ts.BgAttrs); err != nil {
+		return
+	}
+	if s.Padding, err = parseSpacing(ts.Padding); err != nil {
+		return
+	}
+	if s.Margin, err = parseSpacing(ts.Margin); err != nil {
+		return
+	}
+	if ts.HAlign != "" {
+		if s.HAlign, err = lookup(alignNames, "alignment", ts.HAlign); err != nil {
+			return
+		}
+	}
+	if ts.VAlign != "" {
+		if s.VAlign, err = lookup(alignNames, "alignment", ts.VAlign); err != nil {
+			return
+		}
+	}
+	if ts.Border != "" {
+		s.Border, err = parseBorder(ts.Border)
+	}
+	return
+}
+
+// Replace the base styles with a theme read from r.  A theme is a JSON object mapping class selectors, such as
+// "text.border" or "text.border:focus", to styles like
+//
+//	{"fg": "green", "fgAttrs": ["bold"], "padding": [0, 1], "halign": "center", "border": "rounded"}
+func (it *Imterm) LoadTheme(r io.Reader) error {
+	var theme map[string]themeStyle
+	if err := json.NewDecoder(r).Decode(&theme); err != nil {
+		return err
+	}
+	base := map[string]Style{}
+	for name, ts := range theme {
+		s, err := ts.style()
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		base[name] = s
+	}
+	it.baseStyle = base
+	return nil
+}
+
+// Write the base styles to w as a theme that can be read back by LoadTheme
+func (it *Imterm) SaveTheme(w io.Writer) error {
+	theme := map[string]themeStyle{}
+	for name, s := range it.baseStyle {
+		theme[name] = toTheme(s)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(theme)
+}
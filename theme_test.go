@@ -0,0 +1,67 @@
+package imterm
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+type nullScreen struct{}
+
+func (nullScreen) SetCell(x, y int, ch rune, fg, bg Attribute) {}
+func (nullScreen) Size() (w, h int)                            { return 80, 25 }
+func (nullScreen) Flip()                                       {}
+func (nullScreen) Clear(bg Attribute)                          {}
+
+func roundTrip(t *testing.T, it *Imterm) map[string]Style {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := it.SaveTheme(&buf); err != nil {
+		t.Fatalf("SaveTheme: %v", err)
+	}
+	want := it.baseStyle
+	if err := it.LoadTheme(&buf); err != nil {
+		t.Fatalf("LoadTheme: %v\n%s", err, buf.String())
+	}
+	return want
+}
+
+func TestThemeRoundTripAttrs(t *testing.T) {
+	it, _ := New(nullScreen{})
+	it.baseStyle = map[string]Style{}
+	var all Attribute
+	for name, attr := range attrNames {
+		it.baseStyle[name] = Style{FgStyle: attr, BgStyle: attr}
+		all |= attr
+	}
+	it.baseStyle["all"] = Style{FgColor: RGB(1, 2, 3), BgColor: Color256(200), FgStyle: all, BgStyle: all}
+	want := roundTrip(t, it)
+	if !reflect.DeepEqual(it.baseStyle, want) {
+		t.Errorf("round trip changed the theme\ngot  %v\nwant %v", it.baseStyle, want)
+	}
+}
+
+func TestThemeRoundTripBuiltin(t *testing.T) {
+	for _, name := range ThemeNames() {
+		it, _ := New(nullScreen{})
+		if err := it.SetTheme(name); err != nil {
+			t.Fatal(err)
+		}
+		want := roundTrip(t, it)
+		if !reflect.DeepEqual(it.baseStyle, want) {
+			t.Errorf("%s: round trip changed the theme", name)
+		}
+	}
+}
+
+func TestThemeNamesIgnoreCase(t *testing.T) {
+	it, _ := New(nullScreen{})
+	err := it.LoadTheme(bytes.NewBufferString(`{"text": {"fg": "Red", "fgAttrs": ["BOLD", "curlyunderline"]}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Style{FgColor: ColorRed, FgStyle: AttrBold | AttrCurlyUnderline}
+	if got := it.baseStyle["text"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,175 @@
+package imterm
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// palette is the handful of styles a built in theme is made from
+type palette struct {
+	base     Style // text, and the background of the screen
+	border   Style // frames and their labels
+	focus    Style // added to the border and text of the focused item
+	accent   Style // active toggles, tabs and open headers
+	muted    Style // scrollbars, dividers and unchecked boxes
+	disabled Style // every part of a disabled item
+	barOn    Style // the filled part of a gauge
+	barOff   Style // the empty part of a gauge
+	popup    Style // the inside of windows and modals
+	backdrop Style // the screen behind a modal
+	frame    Border
+}
+
+func (p palette) theme() map[string]Style {
+	base := func(s Style) Style {
+		return s.Merge(p.base)
+	}
+	border := base(Style{Border: p.frame}.Merge(p.border))
+	accent := base(p.accent)
+	muted := base(p.muted)
+	popup := p.popup.Merge(p.base)
+	t := map[string]Style{
+		"":             p.base,
+		"border":       border,
+		"border:focus": p.focus,
+		"label":        base(Style{}),
+		"label:focus":  p.focus,
+		"box:focus":    p.focus,
+
+		"text.border":          border,
+		"text.text":            base(Style{}),
+		"input.border":         border,
+		"input.text":           base(Style{}),
+		"button.border":        border,
+		"button.text":          base(Style{}),
+		"toggle.border":        border,
+		"toggle.text":          base(Style{}),
+		"toggle.active.border": accent,
+		"toggle.active.text":   accent,
+		"checkbox.box":         muted,
+		"checkbox.text":        base(Style{}),
+		"radio.box":            muted,
+		"radio.text":           base(Style{}),
+		"header.arrow":         muted,
+		"header.text":          base(Style{}),
+		"header.open.arrow":    accent,
+		"header.open.text":     base(Style{}),
+		"gauge.border":         border,
+		"gauge.bar.on":         base(p.barOn),
+		"gauge.bar.off":        base(p.barOff),
+		"list.border":          border,
+		"list.items":           base(Style{}),
+		"list.items:selected":  Style{FgStyle: AttrReverse, BgStyle: AttrReverse},
+		"tabs.border":          border,
+		"tabs.tab":             base(Style{}),
+		"tabs.tab.active":      accent,
+		"child.border":         border,
+		"child.scrollbar":      muted,
+		"split.divider":        base(Style{Border: p.frame}.Merge(p.muted)),
+		"window.border":        border.Merge(popup),
+		"window.background":    popup,
+		"modal.border":         border.Merge(popup),
+		"modal.background":     popup,
+		"modal.text":           popup,
+		"modal.backdrop":       base(p.backdrop),
+	}
+	for class := range t {
+		if class == "" || strings.Contains(class, ":") {
+			continue
+		}
+		if strings.HasSuffix(class, ".border") || strings.HasSuffix(class, ".text") {
+			t[class+":focus"] = p.focus
+		}
+		t[class+":disabled"] = p.disabled
+	}
+	return t
+}
+
+var themes = map[string]map[string]Style{
+	"default": palette{
+		focus:    Style{FgStyle: AttrBold},
+		accent:   Style{FgColor: ColorGreen},
+		disabled: Style{FgColor: ColorBlack, FgStyle: AttrBold},
+		barOn:    Style{BgColor: ColorRed},
+	}.theme(),
+	"dark": palette{
+		base:     Style{FgColor: ColorWhite, BgColor: ColorBlack},
+		border:   Style{FgColor: ColorBlue},
+		focus:    Style{FgStyle: AttrBold},
+		accent:   Style{FgColor: ColorGreen},
+		muted:    Style{FgColor: ColorCyan},
+		disabled: Style{FgColor: ColorBlack, FgStyle: AttrBold},
+		barOn:    Style{BgColor: ColorBlue},
+		popup:    Style{BgColor: ColorBlack},
+		backdrop: Style{BgColor: ColorBlack},
+		frame:    BorderRounded,
+	}.theme(),
+	"light": palette{
+		base:     Style{FgColor: ColorBlack, BgColor: ColorWhite},
+		border:   Style{FgColor: ColorBlue},
+		focus:    Style{FgStyle: AttrBold},
+		accent:   Style{FgColor: ColorMagenta},
+		muted:    Style{FgColor: ColorBlue},
+		disabled: Style{FgColor: ColorCyan},
+		barOn:    Style{FgColor: ColorWhite, BgColor: ColorBlue},
+		popup:    Style{BgColor: ColorWhite},
+		backdrop: Style{BgColor: ColorWhite},
+		frame:    BorderRounded,
+	}.theme(),
+	"solarized": palette{
+		base:     Style{FgColor: RGB(0x83, 0x94, 0x96), BgColor: RGB(0x00, 0x2b, 0x36)},
+		border:   Style{FgColor: RGB(0x26, 0x8b, 0xd2)},
+		focus:    Style{FgStyle: AttrBold},
+		accent:   Style{FgColor: RGB(0xb5, 0x89, 0x00)},
+		muted:    Style{FgColor: RGB(0x2a, 0xa1, 0x98)},
+		disabled: Style{FgColor: RGB(0x58, 0x6e, 0x75)},
+		barOn:    Style{FgColor: RGB(0x00, 0x2b, 0x36), BgColor: RGB(0xb5, 0x89, 0x00)},
+		barOff:   Style{BgColor: RGB(0x07, 0x36, 0x42)},
+		popup:    Style{BgColor: RGB(0x07, 0x36, 0x42)},
+		backdrop: Style{BgColor: RGB(0x00, 0x2b, 0x36)},
+	}.theme(),
+	"monochrome": palette{
+		focus:    Style{FgStyle: AttrBold},
+		accent:   Style{FgStyle: AttrUnderline},
+		disabled: Style{FgStyle: AttrDim},
+		barOn:    Style{FgStyle: AttrReverse, BgStyle: AttrReverse},
+		frame:    BorderSingle,
+	}.theme(),
+	"high-contrast": palette{
+		base:     Style{FgColor: ColorWhite, BgColor: ColorBlack},
+		border:   Style{FgColor: ColorWhite},
+		focus:    Style{FgColor: ColorYellow, FgStyle: AttrBold},
+		accent:   Style{FgColor: ColorBlack, BgColor: ColorYellow},
+		muted:    Style{FgColor: ColorWhite},
+		disabled: Style{FgColor: ColorBlack, FgStyle: AttrBold},
+		barOn:    Style{FgColor: ColorBlack, BgColor: ColorYellow},
+		popup:    Style{BgColor: ColorBlack},
+		backdrop: Style{BgColor: ColorBlack},
+		frame:    BorderHeavy,
+	}.theme(),
+}
+
+// Replace the base styles with one of the built in themes: default, dark, light, solarized, monochrome or
+// high-contrast
+func (it *Imterm) SetTheme(name string) error {
+	theme, ok := themes[name]
+	if !ok {
+		return fmt.Errorf("imterm: unknown theme %q", name)
+	}
+	it.baseStyle = make(map[string]Style, len(theme))
+	for class, s := range theme {
+		it.baseStyle[class] = s
+	}
+	return nil
+}
+
+// Returns the names of the built in themes
+func ThemeNames() []string {
+	names := make([]string, 0, len(themes))
+	for name := range themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,80 @@
+package imterm
+
+// The helpers in this file let other packages build widgets that behave like the built in ones.  A widget
+// usually takes an ID with GetID, marks itself with SetLast, claims space with GetBox or GetFitBox, clips to
+// that space with PushClip, and then draws with Frame and SetCell while reacting to CheckClick, Focus and
+// KeyPress.  Calling TabStop after SetLast lets the keyboard reach it too.
+
+// State returns the state kept for the item with the given ID, calling init to create it the first time, or if
+// the state kept under that ID has a different type.  The state lives as long as built in widget state does,
+// see StateFrames and KeepState, and is saved by SaveState as JSON, so only its exported fields are kept.
+func State[T any](it *Imterm, id string, init func() T) *T {
+	if state, ok := it.widgetState[id].(*T); ok {
+		it.seen(id)
+		return state
+	}
+	delete(it.widgetState, id)
+	state := new(T)
+	*state = init()
+	it.getState(id, state)
+	return state
+}
+
+// Returns the ID of an item with the given label, taking into account ID and PushID
+func (it *Imterm) GetID(label string) string {
+	return it.getID(label)
+}
+
+// Marks id as the item being placed, which Focus and GetLast refer to
+func (it *Imterm) SetLast(id string) {
+	it.setLast(id)
+}
+
+// Claims space for the next item, sized and positioned the same way as built in items
+func (it *Imterm) GetBox(width, height Size) (x, y, w, h int) {
+	b := it.getBox(width, height)
+	return b.x, b.y, b.w, b.h
+}
+
+// Claims space for the next item like GetBox, using the margin of class and fitW and fitH as the natural size of
+// the item's contents for Fit
+func (it *Imterm) GetFitBox(width, height Size, class string, fitW, fitH int) (x, y, w, h int) {
+	b := it.getFitBox(width, height, class, fixedSize(fitW, fitH))
+	return b.x, b.y, b.w, b.h
+}
+
+// Draws the border of class around the given box, with label set into its top edge, and returns the box left
+// inside the border
+func (it *Imterm) Frame(x, y, w, h int, label string, class string) (ix, iy, iw, ih int) {
+	b := it.frame(Box{x, y, w, h}, label, class)
+	return b.x, b.y, b.w, b.h
+}
+
+// Draws a single cell, clipped and layered like the built in items
+func (it *Imterm) SetCell(x, y int, ch rune, fg, bg Attribute) {
+	it.setCell(x, y, ch, fg, bg)
+}
+
+// Returns the key pressed this frame, with any modifiers held.  Either key or ch is set, or both are 0 if no key
+// was pressed.
+func (it *Imterm) KeyPress() (key Key, ch rune, mod Modifier) {
+	return it.curState.keyPress, it.curState.chPress, it.curState.modPress
+}
+
+// Returns whether the key pressed this frame activates the focused item, as Space and Enter do for buttons.  Only
+// focus given by Tab counts, so an item clicked into focus isn't activated again by the next key.
+func (it *Imterm) Activated() bool {
+	return it.activated()
+}
+
+// Adds the item marked with SetLast to the items Tab and Shift+Tab move the focus through, in the order they are
+// placed.  Disabled items and items behind a modal are skipped.
+func (it *Imterm) TabStop() {
+	it.tabStop()
+}
+
+// Marks the item being placed as selected, or not, so that its styles are refined with :selected, as a
+// checked Checkbox is.  SetLast clears it for the next item.
+func (it *Imterm) SetSelected(selected bool) {
+	it.selected = selected
+}
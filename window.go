@@ -0,0 +1,169 @@
+package imterm
+
+import (
+	"sort"
+)
+
+type layerCell struct {
+	x, y int
+	Cell
+}
+
+// layer collects the cells drawn by a window so windows can be composited in z order
+type layer struct {
+	id    string
+	z     int
+	box   Box
+	cells []layerCell
+}
+
+type windowFrame struct {
+	flow      flow
+	layer     *layer
+	clipStack []Box
+}
+
+type windowState struct {
+	x, y, w, h int
+	z          int
+
+	drag, resize bool
+	dragX, dragY int
+}
+
+func (it *Imterm) layerID() string {
+	if it.layer == nil {
+		return ""
+	}
+	return it.layer.id
+}
+
+func (it *Imterm) startLayers() {
+	it.layer = nil
+	it.layers = it.layers[:0]
+	it.windowStack = it.windowStack[:0]
+
+	it.hoverLayer, it.pointerLayer = "", ""
+	if it.modalOpening != "" {
+		// a modal opened since the last frame covers the whole screen, though it isn't in lastLayers yet
+		it.hoverLayer, it.pointerLayer = it.modalOpening, it.modalOpening
+		return
+	}
+	if it.curState.mouseButton != 0 {
+		it.hoverLayer = it.layerAt(it.curState.mouseX, it.curState.mouseY)
+	}
+	it.pointerLayer = it.layerAt(it.pointerX, it.pointerY)
+}
+
+// layerAt returns the ID of the topmost layer drawn at x, y last frame
+func (it *Imterm) layerAt(x, y int) (id string) {
+	z := 0
+	for _, l := range it.lastLayers {
+		if l.z >= z && l.box.contains(x, y) {
+			id, z = l.id, l.z
+		}
+	}
+	return
+}
+
+func (it *Imterm) finishLayers() {
+	sort.SliceStable(it.layers, func(i, j int) bool {
+		return it.layers[i].z < it.layers[j].z
+	})
+	it.lastLayers = it.lastLayers[:0]
+	for _, l := range it.layers {
+		for _, c := range l.cells {
+			it.screen.SetCell(c.x, c.y, c.Char, it.downsample(c.Fg), it.downsample(c.Bg))
+		}
+		it.lastLayers = append(it.lastLayers, layer{id: l.id, z: l.z, box: l.box})
+	}
+}
+
+// Start a floating window.  x, y, w and h are the initial position and size, after which the user can
+// drag the window by its title bar and resize it from the bottom right corner.  Windows are drawn above
+// the rest of the frame in the order they were last clicked, and only the topmost window under the
+// pointer receives mouse input.  Items placed before the matching EndWindow are laid out inside the window.
+func (it *Imterm) Window(title string, x, y, w, h int) {
+	id := it.getID(title)
+	it.setLast(id)
+	state := it.getState(id, &windowState{x: x, y: y, w: w, h: h}).(*windowState)
+	if state.z == 0 {
+		it.topZ++
+		state.z = it.topZ
+	}
+
+	it.windowStack = append(it.windowStack, windowFrame{it.flow, it.layer, it.clipStack})
+	l := &layer{id: id}
+	it.layers = append(it.layers, l)
+	it.layer = l
+	it.clipStack = nil
+
+	if it.mouseState != MouseLeft {
+		state.drag, state.resize = false, false
+	}
+	if state.drag {
+		state.x, state.y = it.pointerX-state.dragX, it.pointerY-state.dragY
+	}
+	if state.resize {
+		state.w, state.h = it.pointerX-state.x+1, it.pointerY-state.y+1
+	}
+	if it.CheckClick(state.x, state.y, state.w, state.h) == MouseLeft {
+		if state.z != it.topZ {
+			it.topZ++
+			state.z = it.topZ
+		}
+		mx, my := it.curState.mouseX, it.curState.mouseY
+		if mx == state.x+state.w-1 && my == state.y+state.h-1 {
+			state.resize = true
+		} else if my == state.y {
+			state.drag = true
+			state.dragX, state.dragY = mx-state.x, my-state.y
+		}
+	}
+	if state.w < 4 {
+		state.w = 4
+	}
+	if state.h < 3 {
+		state.h = 3
+	}
+	if state.y < 0 {
+		state.y = 0
+	}
+
+	b := Box{state.x, state.y, state.w, state.h}
+	l.z = state.z
+	l.box = b
+	it.lastBox = b
+	it.clipStack = []Box{b.intersect(it.screenBox())}
+
+	bg := it.GetStyle("window.background")
+	for cy := 0; cy < b.h; cy++ {
+		for cx := 0; cx < b.w; cx++ {
+			it.setCell(b.x+cx, b.y+cy, ' ', bg.Fg, bg.Bg)
+		}
+	}
+	in := it.frame(b, title, "window.border")
+	if s := it.GetStyle("window.border"); !s.Border.none() {
+		grip := '◢'
+		if s.Border.ascii() {
+			grip = '+'
+		}
+		it.setCell(b.x+b.w-1, b.y+b.h-1, grip, s.Fg, s.Bg)
+	}
+
+	it.flow = flow{
+		xPos:        in.x,
+		yPos:        in.y,
+		columnX:     in.x,
+		columnY:     in.y,
+		columnWidth: in.w,
+		bottom:      in.y + in.h,
+	}
+}
+
+// Finish the current window and return to the enclosing layout
+func (it *Imterm) EndWindow() {
+	wf := it.windowStack[len(it.windowStack)-1]
+	it.windowStack = it.windowStack[:len(it.windowStack)-1]
+	it.flow, it.layer, it.clipStack = wf.flow, wf.layer, wf.clipStack
+}